@@ -20,31 +20,39 @@ func main() {
 	defer ps.Close()
 
 	ps.OnConnect(func(c *pubsub.Connection) {
-		log.Printf("OnConnect (ID: %d)\n", c.ID)
+		log.Printf("OnConnect (ID: %d)\n", c.ID())
 	})
 
 	ps.OnDisconnect(func(c *pubsub.Connection) {
-		log.Printf("OnDisconnect (ID: %d)\n", c.ID)
+		log.Printf("OnDisconnect (ID: %d)\n", c.ID())
 	})
 
 	ps.OnError(func(c *pubsub.Connection, err error) {
-		log.Printf("OnError (ID: %d), err: %s\n", c.ID, err)
+		if c == nil {
+			log.Printf("OnError, err: %s\n", err)
+			return
+		}
+		log.Printf("OnError (ID: %d), err: %s\n", c.ID(), err)
 	})
 
 	ps.OnInfo(func(c *pubsub.Connection, str string) {
-		log.Printf("OnInfo (ID: %d), str: %s\n", c.ID, str)
+		log.Printf("OnInfo (ID: %d), str: %s\n", c.ID(), str)
 	})
 
 	ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
-		log.Printf("OnMessage (ID: %d), msg: %#v\n", c.ID, msg)
+		log.Printf("OnMessage (ID: %d), msg: %#v\n", c.ID(), msg)
 	})
 
 	ps.OnPing(func(c *pubsub.Connection, start time.Time) {
-		log.Printf("OnPing (ID: %d), start: %d\n", c.ID, start.Unix())
+		log.Printf("OnPing (ID: %d), start: %d\n", c.ID(), start.Unix())
 	})
 
 	ps.OnPong(func(c *pubsub.Connection, start, end time.Time) {
-		log.Printf("OnPong (ID: %d), start: %d, end: %d\n", c.ID, start.Unix(), end.Unix())
+		log.Printf("OnPong (ID: %d), start: %d, end: %d\n", c.ID(), start.Unix(), end.Unix())
+	})
+
+	ps.OnTopicRejected(func(c *pubsub.Connection, topic, reason string) {
+		log.Printf("OnTopicRejected (ID: %d), topic: %s, reason: %s\n", c.ID(), topic, reason)
 	})
 
 	interrupt := make(chan os.Signal, 1)
@@ -66,7 +74,9 @@ func main() {
 					if len([]rune(cmd)) > 7 {
 						param := string([]rune(cmd)[7:len([]rune(cmd))])
 						fmt.Printf("Listen: (%s)\n", param)
-						ps.Listen(ctx, param)
+						if err := wait(ctx, ps.Listen(ctx, pubsub.Topic(param))); err != nil {
+							fmt.Printf("Error: %s\n", err)
+						}
 					} else {
 						fmt.Printf("Parameter is not set\n")
 					}
@@ -74,22 +84,29 @@ func main() {
 					if len([]rune(cmd)) > 9 {
 						param := string([]rune(cmd)[9:len([]rune(cmd))])
 						fmt.Printf("Unlisten: (%s)\n", param)
-						ps.Unlisten(ctx, param)
+						if err := wait(ctx, ps.Unlisten(ctx, pubsub.Topic(param))); err != nil {
+							fmt.Printf("Error: %s\n", err)
+						}
 					} else {
 						fmt.Printf("Parameter is not set\n")
 					}
 				} else if strings.HasPrefix(cmd, "has") {
 					if len([]rune(cmd)) > 4 {
 						param := string([]rune(cmd)[4:len([]rune(cmd))])
-						fmt.Printf("HasTopic: (%#v)\n", ps.HasTopic(param))
+						fmt.Printf("HasTopic: (%#v)\n", ps.HasTopic(pubsub.Topic(param)))
 					} else {
 						fmt.Printf("Parameter is not set\n")
 					}
 				} else if cmd == "status" {
+					status := ps.Status()
 					fmt.Printf("Status:\n")
-					fmt.Printf(" - Connections count: (%d)\n", len(ps.Connections))
-					fmt.Printf(" - Topics: (%#v)\n", ps.Topics())
-					fmt.Printf(" - TopicsCount: (%d)\n", ps.TopicsCount())
+					fmt.Printf(" - Connections count: (%d)\n", len(status.Connections))
+					for _, c := range status.Connections {
+						fmt.Printf(" - Connection %s: (%s), topics: (%#v), rtt: (%s), since: (%s), reconnects: (%d), received: (%d)\n",
+							c.Label, c.State, c.Topics, c.LastRTT, c.ConnectedSince.Format(time.RFC3339), c.Reconnects, c.Received)
+					}
+					fmt.Printf(" - Queued: (%#v)\n", status.Queued)
+					fmt.Printf(" - TopicsCount: (%d)\n", status.TopicsCount())
 				} else if cmd == "help" {
 					fmt.Printf("Help:\n")
 					fmt.Printf(" - listen <topic>\n")
@@ -109,5 +126,20 @@ func main() {
 	}(interrupt)
 
 	<-interrupt
+
+	ctx, cancel := context.WithTimeout(context.Background(), pubsub.DefaultShutdownTimeout)
+	defer cancel()
+	if err := ps.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown, err: %s\n", err)
+	}
+
 	log.Println("Done")
 }
+
+// wait is waiting for answer of API, but not longer than API timeout.
+func wait(ctx context.Context, result *pubsub.Result) error {
+	ctx, cancel := context.WithTimeout(ctx, pubsub.TwitchApiResponseTimeout)
+	defer cancel()
+
+	return result.Wait(ctx)
+}
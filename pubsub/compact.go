@@ -0,0 +1,171 @@
+package pubsub
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// migration is represent of topics moved between connections.
+type migration struct {
+	from    *Connection
+	to      *Connection
+	topics  []string
+	results []*Result
+}
+
+// Compact is move topics to fill connections up to TwitchApiMaxTopics and
+// close emptied ones, new places of topics are chosen by Placement.
+// Topic is listened on new connection before it will be unlistened on
+// old one, so messages are not lost, but they can be duplicated during
+// migration. Topics which LISTEN is not answered before ctx is done stay
+// on old connection. Only acknowledged by API topics are moved.
+// Returns first error of migration, not moved topics stay in place.
+func (p *PubSub) Compact(ctx context.Context) error {
+	p.compacting.Lock()
+	defer p.compacting.Unlock()
+
+	p.Lock()
+	if err := p.check(ctx); err != nil {
+		p.Unlock()
+		return err
+	}
+	migrations := p.planCompaction()
+	p.Unlock()
+
+	if len(migrations) <= 0 {
+		return nil
+	}
+
+	// Wait LISTEN responses without lock, events need it
+	var err error
+	for _, m := range migrations {
+		for _, result := range m.results {
+			if e := result.Wait(ctx); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	for _, m := range migrations {
+		unlistenFrom := []string{}
+		unlistenTo := []string{}
+		for i, topic := range m.topics {
+			// Not answered LISTEN is not success, topic stays in place
+			listened := m.results[i].succeeded()
+			inFrom := m.from.HasTopic(topic)
+			inTo := m.to.HasTopic(topic)
+
+			if !inFrom || !inTo {
+				// Unlistened by user during migration
+				unlistenFrom = append(unlistenFrom, topic)
+				unlistenTo = append(unlistenTo, topic)
+			} else if !listened {
+				// Stay on old connection
+				unlistenTo = append(unlistenTo, topic)
+			} else {
+				unlistenFrom = append(unlistenFrom, topic)
+			}
+		}
+
+		if len(unlistenFrom) > 0 {
+			m.from.RemoveTopics(unlistenFrom)
+		}
+		if len(unlistenTo) > 0 {
+			m.to.RemoveTopics(unlistenTo)
+		}
+	}
+
+	p.closeEmpty()
+
+	return err
+}
+
+// planCompaction is choose connections which will be kept and start LISTEN
+// of topics from others, must be called under lock.
+func (p *PubSub) planCompaction() []*migration {
+	// Connections which gave up can't receive or move topics
+	connections := []*Connection{}
+	for _, c := range p.sortedConnections() {
+		if !c.gaveUp() {
+			connections = append(connections, c)
+		}
+	}
+
+	total := 0
+	free := map[*Connection]int{}
+	for _, c := range connections {
+		count := c.TopicsCount()
+		total += count
+		free[c] = TwitchApiMaxTopics - count
+	}
+
+	// Already compact
+	needed := (total + TwitchApiMaxTopics - 1) / TwitchApiMaxTopics
+	if len(connections) <= needed {
+		return nil
+	}
+
+	// Keep most filled connections
+	sort.SliceStable(connections, func(i, j int) bool {
+		return free[connections[i]] < free[connections[j]]
+	})
+	slots := p.slots(connections[:needed])
+	sources := connections[needed:]
+
+	// Every migration is one LISTEN request, it's counted by connection
+	budget := p.limits.available(time.Now())
+
+	migrations := []*migration{}
+	for _, from := range sources {
+		topics := from.listening()
+
+		list := make([]string, 0, len(topics))
+		for topic := range topics {
+			list = append(list, topic)
+		}
+		sort.Strings(list)
+
+		// Group by destination and token, one LISTEN request can carry
+		// only one token
+		type key struct {
+			to    *Connection
+			token string
+		}
+		groups := map[key][]string{}
+		order := []key{}
+		for _, topic := range list {
+			token := topics[topic]
+			i := p.opts.placement.Place(Topic(topic), token, slots)
+			if i < 0 || i >= len(slots) || slots[i].Free <= 0 {
+				continue
+			}
+			slots[i].add(topic, token)
+
+			k := key{to: slots[i].Connection, token: token}
+			if _, ok := groups[k]; !ok {
+				order = append(order, k)
+			}
+			groups[k] = append(groups[k], topic)
+		}
+
+		for _, k := range order {
+			if budget <= 0 {
+				break
+			}
+			budget--
+
+			migrations = append(migrations, &migration{
+				from:    from,
+				to:      k.to,
+				topics:  groups[k],
+				results: k.to.AddTopicsWithAuth(groups[k], k.token),
+			})
+		}
+	}
+
+	return migrations
+}
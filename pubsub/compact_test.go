@@ -0,0 +1,156 @@
+package pubsub_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compact", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	// churn is listen 120 topics and unlisten 70 of them
+	churn := func(ps *pubsub.PubSub) {
+		for _, result := range ps.ListenMany(ctx, videoTopics(1, 120)...) {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+		for _, result := range ps.UnlistenMany(ctx, append(videoTopics(1, 40), videoTopics(51, 30)...)...) {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+		Expect(ps.TopicsCount()).To(Equal(50))
+	}
+
+	It("move topics and close emptied connections", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		churn(ps)
+		Expect(ps.Connections()).To(HaveLen(3))
+		Expect(ps.Compact(ctx)).To(Succeed())
+
+		Expect(ps.Connections()).To(HaveLen(1))
+		Expect(ps.TopicsCount()).To(Equal(50))
+		for _, state := range ps.TopicStates() {
+			Expect(state).To(Equal(pubsub.TopicListening))
+		}
+
+		// Nothing to do
+		Expect(ps.Compact(ctx)).To(Succeed())
+		Expect(ps.Connections()).To(HaveLen(1))
+	})
+
+	It("keep topics in place if LISTEN is not answered in time", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		churn(ps)
+		before := map[int64]map[string]pubsub.TopicState{}
+		for _, c := range ps.Connections() {
+			before[c.ID] = c.Topics
+		}
+
+		server.SetDelay(300 * time.Millisecond)
+		short, stop := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer stop()
+		Expect(ps.Compact(short)).To(MatchError(context.DeadlineExceeded))
+
+		// Nothing was unlistened on old connections
+		for _, c := range ps.Connections() {
+			Expect(c.Topics).To(Equal(before[c.ID]))
+		}
+
+		// Late LISTEN answers does not break anything
+		time.Sleep(500 * time.Millisecond)
+		Expect(ps.TopicsCount()).To(Equal(50))
+		for _, state := range ps.TopicStates() {
+			Expect(state).To(Equal(pubsub.TopicListening))
+		}
+
+		server.SetDelay(0)
+		Expect(ps.Compact(ctx)).To(Succeed())
+		Expect(ps.Connections()).To(HaveLen(1))
+		Expect(ps.TopicsCount()).To(Equal(50))
+	})
+
+	It("unlisten topic which is moved when LISTEN is not answered in time", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		churn(ps)
+		count := len(server.Frames(pubsub.Listen))
+
+		server.SetDelay(300 * time.Millisecond)
+		short, stop := context.WithTimeout(ctx, 150*time.Millisecond)
+		defer stop()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- ps.Compact(short)
+		}()
+
+		// Unlisten while LISTEN on new connection is not answered
+		Eventually(func() int {
+			return len(server.Frames(pubsub.Listen))
+		}, 5*time.Second).Should(BeNumerically(">", count))
+		Expect(ps.Unlisten(ctx, pubsub.VideoPlayback("101")).Wait(ctx)).To(Succeed())
+
+		Eventually(done, 5*time.Second).Should(Receive(MatchError(context.DeadlineExceeded)))
+		Expect(ps.HasTopic(pubsub.VideoPlayback("101"))).To(BeFalse())
+		Expect(ps.TopicsCount()).To(Equal(49))
+	})
+
+	It("keep messages flowing after compaction", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		messages := make(chan string, 10)
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			messages <- msg.GetData().Topic
+		})
+
+		churn(ps)
+		Expect(ps.Compact(ctx)).To(Succeed())
+
+		server.Publish("video-playback-by-id.120", `{"type":"viewcount","viewers":1}`)
+		Eventually(messages, 5*time.Second).Should(Receive(Equal("video-playback-by-id.120")))
+	})
+
+	It("compact periodically", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithCompactInterval(50*time.Millisecond))
+		defer ps.Close()
+
+		var mu sync.Mutex
+		ids := map[int64]struct{}{}
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			mu.Lock()
+			defer mu.Unlock()
+			ids[c.ID()] = struct{}{}
+		})
+
+		churn(ps)
+
+		// All messages will come from one connection
+		Eventually(func() int {
+			mu.Lock()
+			ids = map[int64]struct{}{}
+			mu.Unlock()
+
+			for _, topic := range ps.Topics() {
+				server.Publish(topic, `{"type":"viewcount","viewers":1}`)
+			}
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			return len(ids)
+		}, 5*time.Second).Should(Equal(1))
+		Expect(ps.TopicsCount()).To(Equal(50))
+	})
+})
@@ -1,200 +1,787 @@
 package pubsub
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-// At least once every 5 minutes by docs but better keep this at 30 seconds
+// At least once every 5 minutes by docs but better keep this at 15 seconds
+// These values are defaults, see PingPolicy
 //
 // https://dev.twitch.tv/docs/pubsub/#connection-management
-const TwitchApiPingEach = 15 * time.Second    // 30 seconds
+const TwitchApiPingEach = 15 * time.Second    // 15 seconds
 const TwitchApiPingTimeout = 10 * time.Second // 10 seconds
 
-var nextConnectionID int64 = 0
+// Maximum time for writing one message to API and size of write queue.
+const TwitchApiWriteTimeout = 10 * time.Second
+const TwitchApiWriteQueue = 256
+
+// IDs of connections created by NewConnection, PubSub has its own counter.
+var connectionIDs atomic.Int64
+
+// outgoing is message in write queue.
+// It will be skipped if connection was changed.
+type outgoing struct {
+	conn *websocket.Conn
+	kind AnswerType
+	msg  []byte
+}
 
 // Connection is represent of one connection.
+// All fields are guarded by mutex, events are always fired without it.
 type Connection struct {
 	sync.RWMutex
 
-	done   chan struct{}
-	topics map[string]*struct{}
-	active bool
-	url    url.URL
+	done     chan struct{}
+	topics   map[string]string     // topic -> auth token
+	states   map[string]TopicState // topic -> state
+	waiters  map[string][]*Result  // topic -> not sent LISTEN results
+	pending  map[string]*request   // nonce -> sent request
+	expired  map[string][]string   // nonce -> topics of timed out LISTEN
+	rejected map[string]string     // topic -> reason
+	active   bool
+	url      url.URL
+
+	ping_start    time.Time
+	ping_sended   bool
+	ping_interval time.Duration
+	ping_history  []time.Duration
+
+	// Websocket connection, it's dropped by closing and reader will
+	// notice it, because reader is only one who handle disconnects
+	conn     *websocket.Conn
+	dropped  bool
+	drop_err error
+
+	// Only writer can write to websocket connection
+	queue chan outgoing
+
+	// Workers queues, nil if messages are handled by reader
+	dispatcher *dispatcher
+
+	// LISTEN requests over rate limit, they are sent by limiter
+	limits  *limiter
+	delayed []listenGroup
+
+	// All connection goroutines
+	wg     sync.WaitGroup
+	closed bool
+	drain  bool
+
+	opts    options
+	gave_up bool
+	backoff bool
 
-	ping_start  time.Time
-	ping_sended bool
+	// Statistics for status
+	connected_at time.Time
+	connects     int
+	received     atomic.Uint64
 
-	ID         int64
-	Connection *websocket.Conn
+	// Connection ID, it's unique per client
+	id int64
 
 	// Events
-	eventOnConnect    func(*Connection)
-	eventOnDisconnect func(*Connection)
-	eventOnError      func(*Connection, error)
-	eventOnInfo       func(*Connection, string)
-	eventOnMessage    func(*Connection, *Answer)
-	eventOnPing       func(*Connection, time.Time)
-	eventOnPong       func(*Connection, time.Time, time.Time)
+	eventOnConnect       handlers[func(*Connection)]
+	eventOnDisconnect    handlers[func(*Connection)]
+	eventOnError         handlers[func(*Connection, error)]
+	eventOnInfo          handlers[func(*Connection, string)]
+	eventOnMessage       handlers[func(*Connection, *Answer)]
+	eventOnPing          handlers[func(*Connection, time.Time)]
+	eventOnPong          handlers[func(*Connection, time.Time, time.Time)]
+	eventOnTopicRejected handlers[func(*Connection, string, string)]
+	eventOnReconnecting  handlers[func(*Connection, int, time.Duration)]
 }
 
-// NewConnection create new connection.
+// NewConnection create new connection with options.
 // Returns pointer to connection.
-func NewConnection(url url.URL) *Connection {
+func NewConnection(url url.URL, opts ...Option) *Connection {
+	return newConnectionWithID(url, connectionIDs.Add(1)-1, nil, opts...)
+}
+
+// newConnectionWithID create connection with ID and LISTEN rate limiter
+// shared with other connections, own limiter is used if it's nil.
+func newConnectionWithID(url url.URL, id int64, limits *limiter, opts ...Option) *Connection {
 	c := &Connection{
-		done:   make(chan struct{}),
-		topics: map[string]*struct{}{},
-		active: false,
-		url:    url,
+		done:     make(chan struct{}),
+		topics:   map[string]string{},
+		states:   map[string]TopicState{},
+		waiters:  map[string][]*Result{},
+		pending:  map[string]*request{},
+		expired:  map[string][]string{},
+		rejected: map[string]string{},
+		active:   false,
+		url:      url,
+
+		ping_start:   time.Now(),
+		ping_sended:  false,
+		ping_history: []time.Duration{},
+
+		conn:  nil,
+		queue: make(chan outgoing, TwitchApiWriteQueue),
+
+		opts: newOptions(opts...),
 
-		ping_start:  time.Now(),
-		ping_sended: false,
+		id: id,
+	}
+
+	c.ping_interval = c.opts.ping.Next()
+	c.dispatcher = newDispatcher(c.opts.dispatch)
 
-		ID:         nextConnectionID,
-		Connection: nil,
+	c.limits = limits
+	if c.limits == nil {
+		c.limits = newLimiter(c.opts.limit)
 	}
 
 	go_reconnector(c)
 	go_reader(c)
+	go_writer(c)
 	go_pinger(c)
 
-	nextConnectionID++
+	if c.dispatcher != nil {
+		for _, queue := range c.dispatcher.queues {
+			go_dispatcher(c, queue)
+		}
+	}
+
+	if c.limits.policy.rated() {
+		go_limiter(c)
+	}
 
 	return c
 }
 
 // -----------------------------------------------------------------------------
 
+// protect is call bound func and fire OnError event with PanicError if it's
+// panicked. Panic of OnError func is ignored to avoid endless loop.
+func (c *Connection) protect(kind EventKind, fn func()) {
+	if err := catch(kind, fn); err != nil && kind != EventError {
+		c.onError(err)
+	}
+}
+
 func (c *Connection) onConnect() {
-	if c.eventOnConnect != nil {
-		c.eventOnConnect(c)
+	for _, fn := range c.eventOnConnect.all() {
+		c.protect(EventConnect, func() { fn(c) })
 	}
 }
 
 func (c *Connection) onDisconnect() {
-	if c.eventOnDisconnect != nil {
-		c.eventOnDisconnect(c)
+	for _, fn := range c.eventOnDisconnect.all() {
+		c.protect(EventDisconnect, func() { fn(c) })
 	}
 }
 
 func (c *Connection) onError(err error) {
-	if c.eventOnError != nil {
-		c.eventOnError(c, err)
+	for _, fn := range c.eventOnError.all() {
+		c.protect(EventError, func() { fn(c, err) })
 	}
 }
 
 func (c *Connection) onInfo(str string) {
-	if c.eventOnInfo != nil {
-		c.eventOnInfo(c, str)
+	for _, fn := range c.eventOnInfo.all() {
+		c.protect(EventInfo, func() { fn(c, str) })
 	}
 }
 
 func (c *Connection) onMessage(msg *Answer) {
-	if c.eventOnMessage != nil {
-		c.eventOnMessage(c, msg)
+	for _, fn := range c.eventOnMessage.all() {
+		c.protect(EventMessage, func() { fn(c, msg) })
 	}
 }
 
 func (c *Connection) onPing(start time.Time) {
-	if c.eventOnPing != nil {
-		c.eventOnPing(c, start)
+	for _, fn := range c.eventOnPing.all() {
+		c.protect(EventPing, func() { fn(c, start) })
 	}
 }
 
 func (c *Connection) onPong(start, end time.Time) {
-	if c.eventOnPong != nil {
-		c.eventOnPong(c, start, end)
+	for _, fn := range c.eventOnPong.all() {
+		c.protect(EventPong, func() { fn(c, start, end) })
+	}
+}
+
+// dispatch is pass message to workers or handle it immediately.
+func (c *Connection) dispatch(msg *Answer) {
+	if c.dispatcher != nil {
+		c.dispatcher.push(msg, c.done)
+		return
+	}
+	c.handleMessage(msg)
+}
+
+// handleMessage is pass message through middlewares to OnMessage event.
+func (c *Connection) handleMessage(msg *Answer) {
+	c.RLock()
+	middlewares := c.opts.middlewares
+	c.RUnlock()
+
+	c.protect(EventMessage, func() {
+		Chain(func(c *Connection, msg *Answer) {
+			c.onMessage(msg)
+		}, middlewares...)(c, msg)
+	})
+}
+
+func (c *Connection) onTopicRejected(topic, reason string) {
+	for _, fn := range c.eventOnTopicRejected.all() {
+		c.protect(EventTopicRejected, func() { fn(c, topic, reason) })
+	}
+}
+
+func (c *Connection) onReconnecting(attempt int, delay time.Duration) {
+	for _, fn := range c.eventOnReconnecting.all() {
+		c.protect(EventReconnecting, func() { fn(c, attempt, delay) })
 	}
 }
 
 // -----------------------------------------------------------------------------
 
-// listenTopis is generate topics and send request to API.
+// resetPing is start new ping interval, must be called under lock.
+func (c *Connection) resetPing() {
+	c.ping_start = time.Now()
+	c.ping_sended = false
+	c.ping_interval = c.opts.ping.Next()
+}
+
+// pong is handle PONG response, must be called under lock.
+// Returns time of PING request.
+func (c *Connection) pong(t time.Time) time.Time {
+	start := c.ping_start
+
+	if c.ping_sended {
+		c.ping_history = append(c.ping_history, t.Sub(start))
+		if len(c.ping_history) > PingHistorySize {
+			c.ping_history = c.ping_history[len(c.ping_history)-PingHistorySize:]
+		}
+	}
+
+	c.resetPing()
+	c.ping_start = t
+
+	return start
+}
+
+// drop is close websocket connection, must be called under lock.
+// Reader will notice it and fire disconnect events with err.
+// Error can be nil, then only disconnect event will be fired.
+func (c *Connection) drop(err error) {
+	if c.conn == nil {
+		return
+	}
+
+	if !c.dropped {
+		c.dropped = true
+		c.drop_err = err
+	}
+
+	_ = c.conn.Close()
+}
+
+// write is put message to write queue, must be called under lock.
+// Returns errNotConnected if connection is not active or broken.
+func (c *Connection) write(answer Answer) error {
+	if c.conn == nil || !c.active || c.dropped {
+		return errNotConnected
+	}
+
+	select {
+	case c.queue <- outgoing{conn: c.conn, kind: answer.Type, msg: answer.JSON()}:
+		return nil
+	default:
+		err := &WriteError{Type: answer.Type, Err: ErrWriteQueue}
+		c.drop(err)
+		return err
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// listenTopis is send all topics to API, used after reconnect because
+// server forget topics, new topics of live connection are sent separately.
 // Also it can close connection because it's API limits.
 // Each connection must listen at least one topic.
 func (c *Connection) listenTopis() {
-	topics := []string{}
-	for topic := range c.topics {
-		topics = append(topics, topic)
+	// One LISTEN request can carry only one auth token
+	// So group topics by token and send request for each group
+	groups := map[string][]string{}
+	for topic, token := range c.topics {
+		groups[token] = append(groups[token], topic)
 	}
 
 	// No topics, close connection
-	if len(topics) <= 0 {
-		c.active = false
-		if c.Connection != nil {
-			c.Connection.Close()
-		}
+	if len(groups) <= 0 {
+		c.resolvePending(ErrUnlistened)
+		c.drop(nil)
 		return
 	}
 
+	tokens := make([]string, 0, len(groups))
+	for token := range groups {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
 	// Send LISTEN request
-	if c.Connection != nil && c.active {
-		// TODO: track bad topics and auto remove? [FUTURE]
-		// One bad topic will break all next topics
-
-		// The error message associated with the request, or an empty string if there is no error.
-		// For Bits and whispers events requests, error responses can be:
-		// ERR_BADMESSAGE, ERR_BADAUTH, ERR_SERVER, ERR_BADTOPIC
-		msg := Answer{Type: Listen, Data: AnswerDataTopics{Topics: topics}}.JSON()
-		if err := c.Connection.WriteMessage(websocket.TextMessage, msg); err != nil {
-			c.onError(err)
-			c.active = false
-			c.onDisconnect()
-		}
+	for _, token := range tokens {
+		topics := groups[token]
+		sort.Strings(topics)
+		c.sendListen(token, topics)
 	}
 }
 
-// -----------------------------------------------------------------------------
+// listenGroup is topics of one LISTEN request.
+type listenGroup struct {
+	token  string
+	topics []string
+}
 
-// AddTopic is adding topics for listening.
-func (c *Connection) AddTopic(topic string) {
-	if _, ok := c.topics[topic]; ok {
+// sendListen is send one LISTEN request for topics with the same token.
+// Request over LISTEN rate limit is delayed, see sendDelayed.
+// Results of topics will be resolved by API response.
+func (c *Connection) sendListen(token string, topics []string) {
+	// Keep order, next requests will wait too
+	if len(c.delayed) > 0 || !c.limits.allow(time.Now()) {
+		c.delayed = append(c.delayed, listenGroup{token: token, topics: topics})
 		return
 	}
+	c.writeListen(token, topics)
+}
 
-	if len(c.topics) >= TwitchApiMaxTopics {
+// sendDelayed is send delayed LISTEN requests while limit allows it.
+// Unlistened topics are skipped.
+func (c *Connection) sendDelayed() {
+	if !c.active || c.conn == nil || c.dropped {
 		return
 	}
 
+	for len(c.delayed) > 0 {
+		group := c.delayed[0]
+
+		topics := make([]string, 0, len(group.topics))
+		for _, topic := range group.topics {
+			if _, ok := c.topics[topic]; ok {
+				topics = append(topics, topic)
+			}
+		}
+
+		if len(topics) > 0 {
+			if !c.limits.allow(time.Now()) {
+				return
+			}
+			c.writeListen(group.token, topics)
+		}
+
+		c.delayed = c.delayed[1:]
+	}
+}
+
+// writeListen is write LISTEN request to API and track it.
+func (c *Connection) writeListen(token string, topics []string) {
+	// The error message associated with the request, or an empty string if there is no error.
+	// For Bits and whispers events requests, error responses can be:
+	// ERR_BADMESSAGE, ERR_BADAUTH, ERR_SERVER, ERR_BADTOPIC
+	nonce := newNonce()
+	// Not sent topics will be sent after reconnect
+	msg := Answer{Type: Listen, Data: AnswerDataTopics{Topics: topics, AuthToken: token}, Nonce: nonce}
+	if err := c.write(msg); err != nil {
+		return
+	}
+
+	results := map[string][]*Result{}
+	for _, topic := range topics {
+		results[topic] = c.waiters[topic]
+		delete(c.waiters, topic)
+	}
+	c.track(nonce, &request{kind: Listen, topics: topics, results: results})
+}
+
+// track is remember sent request and wait response for it.
+func (c *Connection) track(nonce string, req *request) {
+	req.timer = time.AfterFunc(TwitchApiResponseTimeout, func() {
+		c.Lock()
+		req, ok := c.pending[nonce]
+		delete(c.pending, nonce)
+		if ok && req.kind == Listen {
+			c.setState(req.topics, TopicFailed)
+			// API can respond later, topics will be listening then
+			c.expired[nonce] = req.topics
+		}
+		c.Unlock()
+
+		if ok {
+			req.resolve(ErrTimeout)
+		}
+	})
+	c.pending[nonce] = req
+}
+
+// resolveRequest is resolve sent request by API response.
+// Returns error if API respond with error.
+func (c *Connection) resolveRequest(answer *Answer) error {
+	c.Lock()
+	req, ok := c.pending[answer.Nonce]
+	delete(c.pending, answer.Nonce)
+	c.Unlock()
+
+	if !ok {
+		return c.resolveExpired(answer)
+	}
+
+	var err error
+	if answer.HasError() {
+		err = &ResponseError{
+			Type:   req.kind,
+			Nonce:  answer.Nonce,
+			Topics: req.topics,
+			Code:   answer.Error,
+		}
+	}
+
+	// One bad topic will break all topics in request
+	// So send each topic separately to find bad one
+	if req.kind == Listen && isTopicError(err) {
+		if len(req.topics) > 1 {
+			c.isolateTopics(req)
+			return nil
+		}
+		c.rejectTopic(req, answer.Error)
+	}
+
+	if req.kind == Listen {
+		c.Lock()
+		if err != nil {
+			c.setState(req.topics, TopicFailed)
+		} else {
+			c.setState(req.topics, TopicListening)
+		}
+		c.Unlock()
+	}
+
+	req.resolve(err)
+	return err
+}
+
+// resolveExpired is apply late API response to topics of LISTEN request
+// which is already resolved by timeout.
+func (c *Connection) resolveExpired(answer *Answer) error {
 	c.Lock()
 	defer c.Unlock()
 
-	c.topics[topic] = nil
+	topics, ok := c.expired[answer.Nonce]
+	delete(c.expired, answer.Nonce)
 
-	c.listenTopis()
+	if !ok {
+		if answer.HasError() {
+			return errors.New(answer.Error)
+		}
+		return nil
+	}
+
+	if answer.HasError() {
+		return &ResponseError{
+			Type:   Listen,
+			Nonce:  answer.Nonce,
+			Topics: topics,
+			Code:   answer.Error,
+		}
+	}
+
+	// Topics can be listened again after timeout, so only failed are changed
+	for _, topic := range topics {
+		if c.states[topic] == TopicFailed {
+			c.setState([]string{topic}, TopicListening)
+		}
+	}
+	return nil
 }
 
-// RemoveTopic is remove topic from listening.
-func (c *Connection) RemoveTopic(topic string) {
+// setState is change state of topics which are still present,
+// must be called under lock.
+func (c *Connection) setState(topics []string, state TopicState) {
+	for _, topic := range topics {
+		if _, ok := c.topics[topic]; ok {
+			c.states[topic] = state
+		}
+	}
+}
+
+// isTopicError returns true if error is caused by topic itself
+// and repeating of request will not help.
+func isTopicError(err error) bool {
+	return errors.Is(err, ErrBadTopic) || errors.Is(err, ErrBadAuth)
+}
+
+// isolateTopics is send each topic of failed request separately.
+func (c *Connection) isolateTopics(req *request) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, topic := range req.topics {
+		token, ok := c.topics[topic]
+		if !ok {
+			for _, result := range req.results[topic] {
+				result.resolve(ErrUnlistened)
+			}
+			continue
+		}
+
+		c.waiters[topic] = append(c.waiters[topic], req.results[topic]...)
+		c.sendListen(token, []string{topic})
+	}
+}
+
+// rejectTopic is remove bad topic from listening.
+// Topic will not be sent again on reconnect.
+func (c *Connection) rejectTopic(req *request, reason string) {
+	topic := req.topics[0]
+
+	c.Lock()
 	if _, ok := c.topics[topic]; !ok {
+		c.Unlock()
 		return
 	}
 
+	delete(c.topics, topic)
+	delete(c.states, topic)
+	c.rejected[topic] = reason
+
+	// No topics, close connection
+	if len(c.topics) <= 0 {
+		c.drop(nil)
+	}
+	c.Unlock()
+
+	c.onTopicRejected(topic, reason)
+}
+
+// resolvePending is resolve all sent and not sent requests.
+// LISTEN requests will be resolved with err, UNLISTEN requests without.
+func (c *Connection) resolvePending(err error) {
+	c.expired = map[string][]string{}
+
+	for nonce, req := range c.pending {
+		if req.kind == Listen {
+			req.resolve(err)
+		} else {
+			req.resolve(nil)
+		}
+		delete(c.pending, nonce)
+	}
+
+	for topic, results := range c.waiters {
+		for _, result := range results {
+			result.resolve(err)
+		}
+		delete(c.waiters, topic)
+	}
+}
+
+// requeuePending is return sent LISTEN results back to waiters.
+// Need to call after reconnect, because API will not respond
+// to requests from previous connection.
+func (c *Connection) requeuePending() {
+	c.expired = map[string][]string{}
+
+	for nonce, req := range c.pending {
+		req.timer.Stop()
+		if req.kind == Listen {
+			for topic, results := range req.results {
+				if _, ok := c.topics[topic]; ok {
+					c.waiters[topic] = append(c.waiters[topic], results...)
+				} else {
+					for _, result := range results {
+						result.resolve(ErrUnlistened)
+					}
+				}
+			}
+		} else {
+			req.resolve(nil)
+		}
+		delete(c.pending, nonce)
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// AddTopic is adding topics for listening.
+// Returns result which will be resolved by API response.
+func (c *Connection) AddTopic(topic string) *Result {
+	return c.AddTopicWithAuth(topic, "")
+}
+
+// AddTopicWithAuth is adding topics for listening with OAuth token.
+// Token is required by private topics like whispers or channel points.
+// If topic already present, only token will be updated and it will be
+// used on next reconnect.
+//
+// Result error can be ErrTopicLimit, ErrAlreadyListening, ErrClosed,
+// ErrInvalidTopic or API error, see ResponseError.
+//
+// https://dev.twitch.tv/docs/pubsub/#topics
+func (c *Connection) AddTopicWithAuth(topic, token string) *Result {
+	return c.AddTopicsWithAuth([]string{topic}, token)[0]
+}
+
+// AddTopicsWithAuth is adding many topics for listening with one LISTEN
+// request, only new topics will be sent. Returns results in the same order.
+func (c *Connection) AddTopicsWithAuth(topics []string, token string) []*Result {
+	c.Lock()
+	defer c.Unlock()
+
+	results := make([]*Result, 0, len(topics))
+	added := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		result, ok := c.addTopic(topic, token)
+		results = append(results, result)
+		if ok {
+			added = append(added, topic)
+		}
+	}
+
+	// Not connected, all topics will be sent after reconnect
+	if len(added) > 0 && c.active && c.conn != nil && !c.dropped {
+		sort.Strings(added)
+		c.sendListen(token, added)
+	}
+
+	return results
+}
+
+// addTopic is add topic and returns result and true if topic is new,
+// must be called under lock.
+func (c *Connection) addTopic(topic, token string) (*Result, bool) {
+	if err := Topic(topic).Validate(); err != nil {
+		return resolvedResult(err), false
+	}
+
+	if c.closed {
+		return resolvedResult(ErrClosed), false
+	}
+
+	if c.gave_up {
+		return resolvedResult(ErrReconnectAttempts), false
+	}
+
+	// Update token for next reconnects
+	if current, ok := c.topics[topic]; ok {
+		if current == token {
+			return resolvedResult(ErrAlreadyListening), false
+		}
+		c.topics[topic] = token
+		return resolvedResult(nil), false
+	}
+
+	if len(c.topics) >= TwitchApiMaxTopics {
+		return resolvedResult(fmt.Errorf("%w: can't listen more than %d topics", ErrTopicLimit, TwitchApiMaxTopics)), false
+	}
+
+	result := newResult()
+	c.topics[topic] = token
+	c.states[topic] = TopicPending
+	c.waiters[topic] = append(c.waiters[topic], result)
+	delete(c.rejected, topic)
+
+	return result, true
+}
+
+// RemoveTopic is remove topic from listening.
+// Returns result which will be resolved by API response.
+// Result error can be ErrClosed or WriteError.
+func (c *Connection) RemoveTopic(topic string) *Result {
+	return c.RemoveTopics([]string{topic})[0]
+}
+
+// RemoveTopics is remove many topics from listening with one UNLISTEN
+// request. Returns results in the same order.
+func (c *Connection) RemoveTopics(topics []string) []*Result {
 	c.Lock()
 	defer c.Unlock()
 
-	delete(c.topics, topic)
+	results := make([]*Result, len(topics))
+	if c.closed {
+		for i := range results {
+			results[i] = resolvedResult(ErrClosed)
+		}
+		return results
+	}
 
-	// Send UNLISTEN request
-	if c.Connection != nil && c.active {
-		msg := Answer{Type: Unlisten, Data: AnswerDataTopics{Topics: []string{topic}}}.JSON()
-		if err := c.Connection.WriteMessage(websocket.TextMessage, msg); err != nil {
-			c.onError(err)
-			c.active = false
-			c.onDisconnect()
+	removed := map[string][]*Result{}
+	for i, topic := range topics {
+		if _, ok := c.topics[topic]; !ok {
+			if _, ok := removed[topic]; !ok {
+				results[i] = resolvedResult(nil)
+				continue
+			}
 		}
+
+		delete(c.topics, topic)
+		delete(c.states, topic)
+
+		for _, result := range c.waiters[topic] {
+			result.resolve(ErrUnlistened)
+		}
+		delete(c.waiters, topic)
+
+		results[i] = newResult()
+		removed[topic] = append(removed[topic], results[i])
+	}
+
+	if len(removed) <= 0 {
+		return results
 	}
 
 	// No topics, close connection
+	// Nothing to wait, server will forget all topics
 	if len(c.topics) <= 0 {
-		c.active = false
-		if c.Connection != nil {
-			c.Connection.Close()
+		c.resolvePending(ErrUnlistened)
+		c.drop(nil)
+		for _, list := range removed {
+			for _, result := range list {
+				result.resolve(nil)
+			}
+		}
+		return results
+	}
+
+	list := make([]string, 0, len(removed))
+	for topic := range removed {
+		list = append(list, topic)
+	}
+	sort.Strings(list)
+
+	// Send UNLISTEN request
+	// Server forget all topics on disconnect, so it's not error
+	nonce := newNonce()
+	msg := Answer{Type: Unlisten, Data: AnswerDataTopics{Topics: list}, Nonce: nonce}
+	if err := c.write(msg); err != nil {
+		if err == errNotConnected {
+			err = nil
+		}
+		for _, list := range removed {
+			for _, result := range list {
+				result.resolve(err)
+			}
 		}
+		return results
 	}
+
+	c.track(nonce, &request{
+		kind:    Unlisten,
+		topics:  list,
+		results: removed,
+	})
+
+	return results
 }
 
 // RemoveAllTopics is remove all topics from listening.
@@ -202,15 +789,77 @@ func (c *Connection) RemoveAllTopics() {
 	c.Lock()
 	defer c.Unlock()
 
-	c.topics = map[string]*struct{}{}
+	c.topics = map[string]string{}
+	c.states = map[string]TopicState{}
 
 	c.listenTopis()
 }
 
+// ID returns connection ID, it's unique per client and never changed.
+func (c *Connection) ID() int64 {
+	return c.id
+}
+
+// Label returns human-readable name of connection with ID,
+// for example "chat#2", or "#2" if label is not set.
+func (c *Connection) Label() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.label()
+}
+
+// label is unguarded version of Label.
+func (c *Connection) label() string {
+	return fmt.Sprintf("%s#%d", c.opts.label, c.id)
+}
+
+// Status returns snapshot of connection state, topics and statistics.
+func (c *Connection) Status() ConnectionStatus {
+	c.RLock()
+	defer c.RUnlock()
+
+	status := ConnectionStatus{
+		ID:             c.id,
+		Label:          c.label(),
+		URL:            c.url.String(),
+		State:          ConnectionDialing,
+		Topics:         make(map[string]TopicState, len(c.states)),
+		ConnectedSince: c.connected_at,
+		Received:       c.received.Load(),
+	}
+
+	if c.closed || c.gave_up {
+		status.State = ConnectionClosed
+	} else if c.active {
+		status.State = ConnectionConnected
+	} else if c.backoff {
+		status.State = ConnectionBackoff
+	}
+
+	for topic, state := range c.states {
+		status.Topics[topic] = state
+	}
+
+	if len(c.ping_history) > 0 {
+		status.LastRTT = c.ping_history[len(c.ping_history)-1]
+	}
+
+	if c.connects > 1 {
+		status.Reconnects = c.connects - 1
+	}
+
+	if c.dispatcher != nil {
+		status.Dropped = c.dispatcher.dropped.Load()
+	}
+
+	return status
+}
+
 // Topics returns all current listen topics.
 func (c *Connection) Topics() []string {
-	c.Lock()
-	defer c.Unlock()
+	c.RLock()
+	defer c.RUnlock()
 
 	topics := []string{}
 	for topic := range c.topics {
@@ -222,57 +871,243 @@ func (c *Connection) Topics() []string {
 
 // HasTopic returns true if topic present.
 func (c *Connection) HasTopic(topic string) bool {
+	c.RLock()
+	defer c.RUnlock()
+
 	if _, ok := c.topics[topic]; ok {
 		return true
 	}
 	return false
 }
 
+// HasAuthToken returns true if any topic is listening with token.
+func (c *Connection) HasAuthToken(token string) bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, t := range c.topics {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+// TopicStates returns states of all current listen topics.
+func (c *Connection) TopicStates() map[string]TopicState {
+	c.RLock()
+	defer c.RUnlock()
+
+	states := make(map[string]TopicState, len(c.states))
+	for topic, state := range c.states {
+		states[topic] = state
+	}
+
+	return states
+}
+
 // TopicsCount return count of topics.
 func (c *Connection) TopicsCount() int {
+	c.RLock()
+	defer c.RUnlock()
+
 	return len(c.topics)
 }
 
+// RejectedTopics returns topics which was rejected by API
+// with reasons. Such topics will not be listened again.
+func (c *Connection) RejectedTopics() map[string]string {
+	c.RLock()
+	defer c.RUnlock()
+
+	rejected := map[string]string{}
+	for topic, reason := range c.rejected {
+		rejected[topic] = reason
+	}
+
+	return rejected
+}
+
+// listening returns acknowledged by API topics with their tokens.
+func (c *Connection) listening() map[string]string {
+	c.RLock()
+	defer c.RUnlock()
+
+	topics := map[string]string{}
+	for topic, state := range c.states {
+		if state == TopicListening {
+			topics[topic] = c.topics[topic]
+		}
+	}
+
+	return topics
+}
+
+// gaveUp returns true if connection gave up reconnecting.
+func (c *Connection) gaveUp() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.gave_up
+}
+
+// forgetRejected is remove topic from rejected list.
+func (c *Connection) forgetRejected(topic string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.rejected, topic)
+}
+
+// SetReconnectPolicy is change delays between reconnect attempts.
+func (c *Connection) SetReconnectPolicy(policy ReconnectPolicy) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.opts.reconnect = policy
+}
+
+// SetPingPolicy is change ping interval and PONG timeout.
+func (c *Connection) SetPingPolicy(policy PingPolicy) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.opts.ping = policy
+}
+
+// Use adds middlewares around messages handlers.
+func (c *Connection) Use(middlewares ...Middleware) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.opts.middlewares = append(c.opts.middlewares, middlewares...)
+}
+
+// Dropped returns count of messages dropped by dispatch overflow policy.
+func (c *Connection) Dropped() uint64 {
+	if c.dispatcher == nil {
+		return 0
+	}
+	return c.dispatcher.dropped.Load()
+}
+
+// Latency returns last measured ping round-trip times, from oldest.
+func (c *Connection) Latency() []time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+
+	return append([]time.Duration{}, c.ping_history...)
+}
+
 // Close is close connection and shutdown all goroutines.
 // Usually it's need to call before destroying.
+// Queued messages are dropped, it's safe to call it many times.
 func (c *Connection) Close() error {
-	c.active = false
-	close(c.done)
+	c.Lock()
+	defer c.Unlock()
 
-	// It can be not initialized
-	if c.Connection != nil {
-		return c.Connection.Close()
+	if c.closed {
+		return nil
 	}
+	c.closed = true
+
+	close(c.done)
+	c.resolvePending(ErrClosed)
+	c.drop(nil)
 
 	return nil
 }
 
+// Shutdown is gracefully close connection. It sends UNLISTEN request for
+// all topics, handles queued messages and waits for all goroutines.
+// Returns context error if context is done earlier.
+// It's safe to call it many times and after Close.
+func (c *Connection) Shutdown(ctx context.Context) error {
+	var result *Result
+
+	c.Lock()
+	if !c.closed && c.active && len(c.topics) > 0 {
+		topics := make([]string, 0, len(c.topics))
+		for topic := range c.topics {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+
+		nonce := newNonce()
+		msg := Answer{Type: Unlisten, Data: AnswerDataTopics{Topics: topics}, Nonce: nonce}
+		if err := c.write(msg); err == nil {
+			result = newResult()
+			c.track(nonce, &request{
+				kind:    Unlisten,
+				topics:  topics,
+				results: map[string][]*Result{topics[0]: {result}},
+			})
+		}
+	}
+	c.drain = true
+	c.Unlock()
+
+	// Server will forget topics anyway, so error is not important
+	if result != nil {
+		_ = result.Wait(ctx)
+	}
+
+	_ = c.Close()
+
+	return wait(ctx, &c.wg)
+}
+
+// wait is waiting for all goroutines of group.
+// Returns context error if context is done earlier.
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // -----------------------------------------------------------------------------
 
-func (c *Connection) OnConnect(fn func(*Connection)) {
-	c.eventOnConnect = fn
+func (c *Connection) OnConnect(fn func(*Connection)) func() {
+	return c.eventOnConnect.add(fn)
+}
+
+func (c *Connection) OnDisconnect(fn func(*Connection)) func() {
+	return c.eventOnDisconnect.add(fn)
+}
+
+func (c *Connection) OnError(fn func(*Connection, error)) func() {
+	return c.eventOnError.add(fn)
 }
 
-func (c *Connection) OnDisconnect(fn func(*Connection)) {
-	c.eventOnDisconnect = fn
+func (c *Connection) OnInfo(fn func(*Connection, string)) func() {
+	return c.eventOnInfo.add(fn)
 }
 
-func (c *Connection) OnError(fn func(*Connection, error)) {
-	c.eventOnError = fn
+func (c *Connection) OnMessage(fn func(*Connection, *Answer)) func() {
+	return c.eventOnMessage.add(fn)
 }
 
-func (c *Connection) OnInfo(fn func(*Connection, string)) {
-	c.eventOnInfo = fn
+func (c *Connection) OnPing(fn func(*Connection, time.Time)) func() {
+	return c.eventOnPing.add(fn)
 }
 
-func (c *Connection) OnMessage(fn func(*Connection, *Answer)) {
-	c.eventOnMessage = fn
+func (c *Connection) OnPong(fn func(*Connection, time.Time, time.Time)) func() {
+	return c.eventOnPong.add(fn)
 }
 
-func (c *Connection) OnPing(fn func(*Connection, time.Time)) {
-	c.eventOnPing = fn
+func (c *Connection) OnTopicRejected(fn func(*Connection, string, string)) func() {
+	return c.eventOnTopicRejected.add(fn)
 }
 
-func (c *Connection) OnPong(fn func(*Connection, time.Time, time.Time)) {
-	c.eventOnPong = fn
+func (c *Connection) OnReconnecting(fn func(*Connection, int, time.Duration)) func() {
+	return c.eventOnReconnecting.add(fn)
 }
@@ -0,0 +1,97 @@
+package pubsub
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// DispatchPolicy is represent of asynchronous messages dispatching.
+// By default messages are handled by connection reader, so slow handler
+// will block reading and connection can be dropped by PONG timeout.
+//
+// Messages of same topic are always handled by same worker in order.
+type DispatchPolicy struct {
+	// Count of workers of every connection
+	// Zero means messages are handled by reader
+	Workers int
+
+	// Queue size of every worker
+	Queue int
+
+	// What to do when worker queue is full
+	Overflow OverflowPolicy
+}
+
+// dispatcher is queues of connection workers.
+type dispatcher struct {
+	queues   []chan *Answer
+	overflow OverflowPolicy
+	dropped  atomic.Uint64
+}
+
+func newDispatcher(policy DispatchPolicy) *dispatcher {
+	if policy.Workers <= 0 {
+		return nil
+	}
+
+	queue := policy.Queue
+	if queue < 0 {
+		queue = 0
+	}
+
+	d := &dispatcher{
+		queues:   make([]chan *Answer, policy.Workers),
+		overflow: policy.Overflow,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan *Answer, queue)
+	}
+
+	return d
+}
+
+// queue returns worker queue of topic.
+func (d *dispatcher) queue(topic string) chan *Answer {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(topic))
+	return d.queues[h.Sum32()%uint32(len(d.queues))]
+}
+
+// push is add message to worker queue by overflow policy.
+func (d *dispatcher) push(msg *Answer, done chan struct{}) {
+	q := d.queue(msg.GetData().Topic)
+
+	switch d.overflow {
+	case OverflowDropNewest:
+		select {
+		case q <- msg:
+		default:
+			d.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case q <- msg:
+				return
+			default:
+			}
+
+			// Nothing to drop in unbuffered queue
+			if cap(q) <= 0 {
+				d.dropped.Add(1)
+				return
+			}
+
+			select {
+			case <-q:
+				d.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case q <- msg:
+		case <-done:
+		}
+	}
+}
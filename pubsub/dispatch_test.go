@@ -0,0 +1,84 @@
+package pubsub_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dispatch", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	It("keep order of topic messages", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithDispatchPolicy(pubsub.DispatchPolicy{
+			Workers: 4,
+			Queue:   8,
+		}))
+		defer ps.Close()
+
+		var mu sync.Mutex
+		messages := map[string][]string{}
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			mu.Lock()
+			defer mu.Unlock()
+			data := msg.GetData()
+			messages[data.Topic] = append(messages[data.Topic], data.Message)
+		})
+
+		expected := map[string][]string{}
+		for _, topic := range videoTopics(1, 3) {
+			Expect(ps.Listen(ctx, topic).Wait(ctx)).To(Succeed())
+		}
+		for i := 0; i < 30; i++ {
+			for _, t := range videoTopics(1, 3) {
+				topic := t.String()
+				message := fmt.Sprintf(`{"type":"viewcount","viewers":%d}`, i)
+				expected[topic] = append(expected[topic], message)
+				server.Publish(topic, message)
+			}
+		}
+
+		Eventually(func() map[string][]string {
+			mu.Lock()
+			defer mu.Unlock()
+			result := map[string][]string{}
+			for topic, list := range messages {
+				result[topic] = append([]string{}, list...)
+			}
+			return result
+		}, 5*time.Second).Should(Equal(expected))
+		Expect(ps.Dropped()).To(BeZero())
+	})
+
+	It("drop messages on overflow and count them", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithDispatchPolicy(pubsub.DispatchPolicy{
+			Workers:  1,
+			Queue:    1,
+			Overflow: pubsub.OverflowDropNewest,
+		}))
+		defer ps.Close()
+
+		release := make(chan struct{})
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			<-release
+		})
+		defer close(release)
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		for i := 0; i < 5; i++ {
+			server.Publish("video-playback-by-id.1", fmt.Sprintf(`{"type":"viewcount","viewers":%d}`, i))
+		}
+
+		// Reader is not blocked by slow handler
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("2")).Wait(ctx)).To(Succeed())
+		Eventually(ps.Dropped, 5*time.Second).Should(BeNumerically("==", 3))
+	})
+})
@@ -0,0 +1,222 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventKind is kind of event fired by connection.
+type EventKind string
+
+const (
+	EventConnect       EventKind = "connect"
+	EventDisconnect    EventKind = "disconnect"
+	EventError         EventKind = "error"
+	EventInfo          EventKind = "info"
+	EventMessage       EventKind = "message"
+	EventPing          EventKind = "ping"
+	EventPong          EventKind = "pong"
+	EventTopicRejected EventKind = "topic_rejected"
+	EventReconnecting  EventKind = "reconnecting"
+	EventTopicQueued   EventKind = "topic_queued"
+)
+
+func (k EventKind) String() string {
+	return string(k)
+}
+
+// Event is represent of event fired by connection.
+// Use type switch to get event data.
+type Event interface {
+	Kind() EventKind
+	Conn() *Connection
+}
+
+type ConnectEvent struct {
+	Connection *Connection
+}
+
+type DisconnectEvent struct {
+	Connection *Connection
+}
+
+type ErrorEvent struct {
+	Connection *Connection // nil for errors of PubSub itself
+	Err        error
+}
+
+type InfoEvent struct {
+	Connection *Connection
+	Info       string
+}
+
+type MessageEvent struct {
+	Connection *Connection
+	Message    *Answer
+}
+
+type PingEvent struct {
+	Connection *Connection
+	Start      time.Time
+}
+
+type PongEvent struct {
+	Connection *Connection
+	Start      time.Time
+	End        time.Time
+}
+
+type TopicRejectedEvent struct {
+	Connection *Connection
+	Topic      string
+	Reason     string
+}
+
+type ReconnectingEvent struct {
+	Connection *Connection
+	Attempt    int
+	Delay      time.Duration
+}
+
+// TopicQueuedEvent is fired by PubSub, so it has no connection.
+type TopicQueuedEvent struct {
+	Topic string
+}
+
+func (e ConnectEvent) Kind() EventKind       { return EventConnect }
+func (e DisconnectEvent) Kind() EventKind    { return EventDisconnect }
+func (e ErrorEvent) Kind() EventKind         { return EventError }
+func (e InfoEvent) Kind() EventKind          { return EventInfo }
+func (e MessageEvent) Kind() EventKind       { return EventMessage }
+func (e PingEvent) Kind() EventKind          { return EventPing }
+func (e PongEvent) Kind() EventKind          { return EventPong }
+func (e TopicRejectedEvent) Kind() EventKind { return EventTopicRejected }
+func (e ReconnectingEvent) Kind() EventKind  { return EventReconnecting }
+func (e TopicQueuedEvent) Kind() EventKind   { return EventTopicQueued }
+
+func (e ConnectEvent) Conn() *Connection       { return e.Connection }
+func (e DisconnectEvent) Conn() *Connection    { return e.Connection }
+func (e ErrorEvent) Conn() *Connection         { return e.Connection }
+func (e InfoEvent) Conn() *Connection          { return e.Connection }
+func (e MessageEvent) Conn() *Connection       { return e.Connection }
+func (e PingEvent) Conn() *Connection          { return e.Connection }
+func (e PongEvent) Conn() *Connection          { return e.Connection }
+func (e TopicRejectedEvent) Conn() *Connection { return e.Connection }
+func (e ReconnectingEvent) Conn() *Connection  { return e.Connection }
+func (e TopicQueuedEvent) Conn() *Connection   { return nil }
+
+// -----------------------------------------------------------------------------
+
+// OverflowPolicy is what to do when buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock is wait for free space in buffer
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest is drop new event
+	OverflowDropNewest
+
+	// OverflowDropOldest is drop oldest event from buffer and add new one
+	OverflowDropOldest
+)
+
+// Default buffer size of events stream.
+const DefaultEventsBuffer = 64
+
+// EventsOption is configure events stream.
+type EventsOption func(*subscriber)
+
+// WithEventsBuffer sets buffer size of events stream.
+func WithEventsBuffer(size int) EventsOption {
+	return func(s *subscriber) {
+		if size >= 0 {
+			s.size = size
+		}
+	}
+}
+
+// WithEventsOverflow sets what to do when stream buffer is full.
+// By default it will wait for receiver, and it will block connection
+// reader, so receiver must be fast enough.
+func WithEventsOverflow(policy OverflowPolicy) EventsOption {
+	return func(s *subscriber) {
+		s.policy = policy
+	}
+}
+
+// subscriber is represent of one events stream.
+type subscriber struct {
+	sync.Mutex
+
+	ctx    context.Context
+	ch     chan Event
+	size   int
+	policy OverflowPolicy
+	closed bool
+}
+
+func newSubscriber(ctx context.Context, opts ...EventsOption) *subscriber {
+	s := &subscriber{
+		ctx:    ctx,
+		size:   DefaultEventsBuffer,
+		policy: OverflowBlock,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ch = make(chan Event, s.size)
+	return s
+}
+
+// send is deliver event to stream by overflow policy.
+func (s *subscriber) send(e Event) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	switch s.policy {
+	case OverflowDropNewest:
+		select {
+		case s.ch <- e:
+		default:
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.ch <- e:
+				return
+			default:
+			}
+
+			// Nothing to drop in unbuffered stream
+			if cap(s.ch) <= 0 {
+				return
+			}
+
+			select {
+			case <-s.ch:
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- e:
+		case <-s.ctx.Done():
+		}
+	}
+}
+
+func (s *subscriber) close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
@@ -0,0 +1,99 @@
+package pubsub_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Events", func() {
+	var ctx context.Context
+	var server *fakeServer
+	var ps *pubsub.PubSub
+	withFakeServer(&ctx, &server)
+
+	// next returns next event of kind from stream
+	next := func(events <-chan pubsub.Event, kind pubsub.EventKind) pubsub.Event {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case e := <-events:
+				if e != nil && e.Kind() == kind {
+					return e
+				}
+			case <-timeout:
+				Fail("no " + kind.String() + " event")
+				return nil
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		ps = pubsub.NewWithURL(server.URL())
+	})
+
+	AfterEach(func() {
+		ps.Close()
+	})
+
+	It("deliver events to every stream", func() {
+		events1 := ps.Events(ctx)
+		events2 := ps.Events(ctx, pubsub.WithEventsBuffer(256))
+
+		ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)
+
+		for _, events := range []<-chan pubsub.Event{events1, events2} {
+			e := next(events, pubsub.EventConnect)
+			Expect(e).To(BeAssignableToTypeOf(pubsub.ConnectEvent{}))
+			Expect(e.Conn()).NotTo(BeNil())
+		}
+
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+
+		for _, events := range []<-chan pubsub.Event{events1, events2} {
+			e := next(events, pubsub.EventMessage).(pubsub.MessageEvent)
+			Expect(e.Message.GetData().Topic).To(Equal("video-playback-by-id.1"))
+		}
+	})
+
+	It("close stream when context is done", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		events := ps.Events(ctx)
+		cancel()
+		Eventually(events).Should(BeClosed())
+	})
+
+	It("drop newest events on overflow", func() {
+		events := ps.Events(ctx, pubsub.WithEventsBuffer(1), pubsub.WithEventsOverflow(pubsub.OverflowDropNewest))
+
+		ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":2}`)
+		time.Sleep(100 * time.Millisecond)
+
+		Expect(<-events).To(BeAssignableToTypeOf(pubsub.InfoEvent{}))
+	})
+
+	It("drop oldest events on overflow", func() {
+		events := ps.Events(ctx, pubsub.WithEventsBuffer(1), pubsub.WithEventsOverflow(pubsub.OverflowDropOldest))
+
+		ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":2}`)
+
+		Eventually(func() string {
+			select {
+			case e := <-events:
+				if e, ok := e.(pubsub.MessageEvent); ok {
+					return e.Message.GetData().Message
+				}
+			default:
+			}
+			return ""
+		}, 5*time.Second).Should(Equal(`{"type":"viewcount","viewers":2}`))
+	})
+})
@@ -0,0 +1,35 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+)
+
+// Compaction must wait longer than LISTEN response timeout, otherwise
+// topics will be left on old connections with late LISTEN on new ones.
+const compactTimeout = 2 * TwitchApiResponseTimeout
+
+func go_compactor(p *PubSub, interval time.Duration) {
+	p.wg.Add(1)
+	go func(p *PubSub) {
+		defer p.wg.Done()
+
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(interval):
+				ctx, cancel := context.WithTimeout(context.Background(), compactTimeout)
+				go func() {
+					select {
+					case <-p.done:
+						cancel()
+					case <-ctx.Done():
+					}
+				}()
+				_ = p.Compact(ctx)
+				cancel()
+			}
+		}
+	}(p)
+}
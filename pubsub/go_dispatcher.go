@@ -0,0 +1,30 @@
+package pubsub
+
+func go_dispatcher(c *Connection, queue chan *Answer) {
+	c.wg.Add(1)
+	go func(c *Connection) {
+		defer c.wg.Done()
+
+		for {
+			select {
+			case <-c.done:
+				// Handle queued messages on graceful shutdown
+				c.RLock()
+				drain := c.drain
+				c.RUnlock()
+
+				for drain {
+					select {
+					case msg := <-queue:
+						c.handleMessage(msg)
+					default:
+						return
+					}
+				}
+				return
+			case msg := <-queue:
+				c.handleMessage(msg)
+			}
+		}
+	}(c)
+}
@@ -0,0 +1,23 @@
+package pubsub
+
+import (
+	"time"
+)
+
+func go_limiter(c *Connection) {
+	c.wg.Add(1)
+	go func(c *Connection) {
+		defer c.wg.Done()
+
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(queuePoll):
+				c.Lock()
+				c.sendDelayed()
+				c.Unlock()
+			}
+		}
+	}(c)
+}
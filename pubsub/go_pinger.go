@@ -3,32 +3,39 @@ package pubsub
 import (
 	"fmt"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 func go_pinger(c *Connection) {
 	// Pinger (sender)
+	c.wg.Add(1)
 	go func(c *Connection) {
+		defer c.wg.Done()
+
 		for {
+			c.RLock()
+			poll := c.opts.ping.poll()
+			c.RUnlock()
+
 			select {
-			case <-time.After(1 * time.Second):
+			case <-time.After(poll):
+				sended := false
+
+				c.Lock()
 				if c.active && !c.ping_sended {
-					if time.Since(c.ping_start) > TwitchApiPingEach {
-						if err := c.Connection.WriteMessage(
-							websocket.TextMessage,
-							Answer{Type: Ping}.JSON(),
-						); err != nil {
-							c.onError(err)
-							c.active = false
-							c.onDisconnect()
-						} else {
+					if time.Since(c.ping_start) > c.ping_interval {
+						if err := c.write(Answer{Type: Ping}); err == nil {
 							c.ping_start = time.Now()
 							c.ping_sended = true
-							c.onPing(c.ping_start)
+							sended = true
 						}
 					}
 				}
+				start := c.ping_start
+				c.Unlock()
+
+				if sended {
+					c.onPing(start)
+				}
 			case <-c.done:
 				return
 			}
@@ -36,22 +43,32 @@ func go_pinger(c *Connection) {
 	}(c)
 
 	// Pinger (handler)
+	c.wg.Add(1)
 	go func(c *Connection) {
+		defer c.wg.Done()
+
 		for {
+			c.RLock()
+			poll := c.opts.ping.poll()
+			c.RUnlock()
+
 			select {
-			case <-time.After(1 * time.Second):
+			case <-time.After(poll):
+				timeout := time.Duration(0)
+
+				c.Lock()
 				if c.active && c.ping_sended {
-					if time.Since(c.ping_start) > TwitchApiPingTimeout {
-						c.onInfo(fmt.Sprintf("warning, no %s response more than %d seconds", Pong, TwitchApiPingTimeout))
-						c.active = false
-						c.onDisconnect()
-						c.ping_start = time.Now()
-						c.ping_sended = false
-						if err := c.Connection.Close(); err != nil {
-							c.onError(err)
-						}
+					if time.Since(c.ping_start) > c.opts.ping.timeout() {
+						timeout = c.opts.ping.timeout()
+						c.resetPing()
+						c.drop(nil)
 					}
 				}
+				c.Unlock()
+
+				if timeout > 0 {
+					c.onInfo(fmt.Sprintf("warning, no %s response more than %s", Pong, timeout))
+				}
 			case <-c.done:
 				return
 			}
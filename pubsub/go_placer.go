@@ -0,0 +1,24 @@
+package pubsub
+
+import (
+	"time"
+)
+
+// How often queued topics are checked
+const queuePoll = 100 * time.Millisecond
+
+func go_placer(p *PubSub) {
+	p.wg.Add(1)
+	go func(p *PubSub) {
+		defer p.wg.Done()
+
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(queuePoll):
+				p.placeQueued()
+			}
+		}
+	}(p)
+}
@@ -7,25 +7,47 @@ import (
 )
 
 func go_reader(c *Connection) {
+	c.wg.Add(1)
 	go func(c *Connection) {
+		defer c.wg.Done()
+
 		for {
 			select {
 			case <-c.done:
 				return
 			default:
-				if c.active {
-					_, msg, err := c.Connection.ReadMessage()
+				c.RLock()
+				conn := c.conn
+				active := c.active
+				c.RUnlock()
+
+				if active && conn != nil {
+					_, msg, err := conn.ReadMessage()
 					if err != nil {
-						c.onError(err)
+						// Reader is only one who handle disconnects
+						// Connection can be dropped by others with reason
+						c.Lock()
+						if c.dropped {
+							err = c.drop_err
+						}
+						c.conn = nil
 						c.active = false
-						c.onDisconnect()
+						c.connected_at = time.Time{}
+						c.delayed = nil
+						c.dropped = false
+						c.drop_err = nil
 
-						// Wait 1 second or return immediately
-						select {
-						case <-time.After(time.Second):
-						case <-c.done:
-							return
+						// Server forget all topics
+						for topic := range c.states {
+							c.states[topic] = TopicPending
 						}
+						c.Unlock()
+
+						if err != nil {
+							c.onError(err)
+						}
+						c.onDisconnect()
+						_ = conn.Close()
 					} else {
 						var answer Answer
 						if err := json.Unmarshal(msg, &answer); err != nil {
@@ -33,27 +55,25 @@ func go_reader(c *Connection) {
 						} else {
 							if answer.Type == Pong {
 								ct := time.Now()
-								c.onPong(c.ping_start, ct)
-								c.ping_start = ct
-								c.ping_sended = false
+								c.Lock()
+								start := c.pong(ct)
+								c.Unlock()
+								c.onPong(start, ct)
 							} else if answer.Type == Reconnect {
 								c.onInfo(fmt.Sprintf("warning, got %s response", Reconnect))
-								c.active = false
-								c.onDisconnect()
-								c.ping_start = time.Now()
-								c.ping_sended = false
-								if err := c.Connection.Close(); err != nil {
-									c.onError(err)
-								}
+								c.Lock()
+								c.drop(nil)
+								c.Unlock()
 							} else if answer.Type == Response {
-								if answer.HasError() {
-									c.onError(fmt.Errorf(answer.Error))
+								if err := c.resolveRequest(&answer); err != nil {
+									c.onError(err)
 								} else {
 									c.onInfo(fmt.Sprintf("type: %s, data: %#v", answer.Type, answer.Data))
 								}
 							} else {
 								(&answer).Parse()
-								c.onMessage(&answer)
+								c.received.Add(1)
+								c.dispatch(&answer)
 							}
 						}
 					}
@@ -1,41 +1,102 @@
 package pubsub
 
 import (
+	"context"
 	"fmt"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 func go_reconnector(c *Connection) {
+	c.wg.Add(1)
 	go func(c *Connection) {
+		defer c.wg.Done()
+
+		// Count of failed attempts in a row
+		attempt := 0
+
+		// Interrupt dialing on close
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-c.done
+			cancel()
+		}()
+
 		for {
 			select {
 			case <-c.done:
 				return
 			default:
-				if !c.active && len(c.topics) > 0 {
+				c.RLock()
+				need := !c.active && c.conn == nil && len(c.topics) > 0
+				policy := c.opts.reconnect
+				dialer := c.opts.dialer
+				header := c.opts.header.Clone()
+				c.RUnlock()
+
+				if need {
 					c.onInfo(fmt.Sprintf("reconnecting to: %s", c.url.String()))
-					conn, _, err := websocket.DefaultDialer.Dial(c.url.String(), nil)
+					conn, _, err := dialer.DialContext(ctx, c.url.String(), header)
 					if err != nil {
 						c.onError(err)
+						attempt++
 
-						// Wait 1 second or return immediately
+						// Give up, connection is useless now
+						if policy.Exceeded(attempt) {
+							c.onError(ErrReconnectAttempts)
+							c.Lock()
+							c.gave_up = true
+							for topic := range c.states {
+								c.states[topic] = TopicFailed
+							}
+							c.resolvePending(ErrReconnectAttempts)
+							c.Unlock()
+							return
+						}
+
+						// Wait with backoff or return immediately
+						delay := policy.Delay(attempt)
+						c.onReconnecting(attempt+1, delay)
+						c.Lock()
+						c.backoff = true
+						c.Unlock()
 						select {
-						case <-time.After(time.Second):
+						case <-time.After(delay):
 						case <-c.done:
 							return
 						}
+						c.Lock()
+						c.backoff = false
+						c.Unlock()
 					} else {
-						c.onInfo("reconnected successfully")
-						c.ping_start = time.Now()
-						c.ping_sended = false
-						c.Connection = conn
+						attempt = 0
+
+						c.Lock()
+
+						// Connection can be closed while dialing
+						select {
+						case <-c.done:
+							c.Unlock()
+							_ = conn.Close()
+							return
+						default:
+						}
+
+						c.resetPing()
+						c.conn = conn
 						c.active = true
+						c.connected_at = time.Now()
+						c.connects++
+						c.Unlock()
+
+						c.onInfo("reconnected successfully")
 						c.onConnect()
 
 						// Listen all topics
+						c.Lock()
+						c.requeuePending()
 						c.listenTopis()
+						c.Unlock()
 					}
 				} else {
 					// Wait 1 second or return immediately
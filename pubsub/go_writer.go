@@ -0,0 +1,39 @@
+package pubsub
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func go_writer(c *Connection) {
+	c.wg.Add(1)
+	go func(c *Connection) {
+		defer c.wg.Done()
+
+		for {
+			select {
+			case <-c.done:
+				return
+			case out := <-c.queue:
+				// Skip messages for previous connections
+				c.RLock()
+				actual := c.conn == out.conn && !c.dropped
+				c.RUnlock()
+
+				if !actual {
+					continue
+				}
+
+				_ = out.conn.SetWriteDeadline(time.Now().Add(TwitchApiWriteTimeout))
+				if err := out.conn.WriteMessage(websocket.TextMessage, out.msg); err != nil {
+					c.Lock()
+					if c.conn == out.conn {
+						c.drop(&WriteError{Type: out.kind, Err: err})
+					}
+					c.Unlock()
+				}
+			}
+		}
+	}(c)
+}
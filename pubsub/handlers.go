@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// handlers is list of funcs bound to one event.
+type handlers[F any] struct {
+	sync.RWMutex
+
+	next uint64
+	list []handler[F]
+}
+
+type handler[F any] struct {
+	id uint64
+	fn F
+}
+
+// add is bind func and returns func which unbind it.
+func (h *handlers[F]) add(fn F) func() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.next++
+	id := h.next
+	h.list = append(h.list, handler[F]{id: id, fn: fn})
+
+	return func() {
+		h.Lock()
+		defer h.Unlock()
+
+		for i, item := range h.list {
+			if item.id == id {
+				h.list = append(h.list[:i:i], h.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// all returns all bound funcs in order of binding.
+func (h *handlers[F]) all() []F {
+	h.RLock()
+	defer h.RUnlock()
+
+	list := make([]F, 0, len(h.list))
+	for _, item := range h.list {
+		list = append(list, item.fn)
+	}
+
+	return list
+}
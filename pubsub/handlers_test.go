@@ -0,0 +1,73 @@
+package pubsub_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handlers", func() {
+	var ctx context.Context
+	var server *fakeServer
+	var ps *pubsub.PubSub
+	withFakeServer(&ctx, &server)
+
+	BeforeEach(func() {
+		ps = pubsub.NewWithURL(server.URL())
+	})
+
+	AfterEach(func() {
+		ps.Close()
+	})
+
+	It("call all bound funcs and unbind them", func() {
+		var mu sync.Mutex
+		calls := []string{}
+		bind := func(name string) func() {
+			return ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, name)
+			})
+		}
+		get := func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string{}, calls...)
+		}
+
+		unbind1 := bind("first")
+		bind("second")
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+		Eventually(get, 5*time.Second).Should(Equal([]string{"first", "second"}))
+
+		unbind1()
+		unbind1()
+
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":2}`)
+		Eventually(get, 5*time.Second).Should(Equal([]string{"first", "second", "second"}))
+	})
+
+	It("apply bound funcs to live connections", func() {
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+
+		messages := make(chan *pubsub.Answer, 1)
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			messages <- msg
+		})
+
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+
+		var msg *pubsub.Answer
+		Eventually(messages, 5*time.Second).Should(Receive(&msg))
+		Expect(msg.GetData().Topic).To(Equal("video-playback-by-id.1"))
+	})
+})
@@ -0,0 +1,88 @@
+package pubsub_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IDs", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	It("be unique for concurrent connections", func() {
+		u := url.URL{Scheme: "ws", Host: "example.com", Path: ""}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		ids := map[int64]struct{}{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := pubsub.NewConnection(u)
+				defer c.Close()
+
+				mu.Lock()
+				ids[c.ID()] = struct{}{}
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		Expect(ids).To(HaveLen(20))
+	})
+
+	It("be counted per client", func() {
+		clients := []*pubsub.PubSub{
+			pubsub.NewWithURL(server.URL(), pubsub.WithLabel("first")),
+			pubsub.NewWithURL(server.URL(), pubsub.WithLabel("second")),
+		}
+
+		var wg sync.WaitGroup
+		for _, ps := range clients {
+			defer ps.Close()
+
+			wg.Add(1)
+			go func(ps *pubsub.PubSub) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				for _, result := range ps.ListenMany(ctx, videoTopics(1, 60)...) {
+					Expect(result.Wait(ctx)).To(Succeed())
+				}
+			}(ps)
+		}
+		wg.Wait()
+
+		for i, name := range []string{"first", "second"} {
+			ps := clients[i]
+			connections := ps.Connections()
+			Expect(connections).To(HaveLen(2))
+			Expect(connections[0].ID).To(Equal(int64(0)))
+			Expect(connections[1].ID).To(Equal(int64(1)))
+			Expect(connections[0].Label).To(Equal(name + "#0"))
+			Expect(connections[1].Label).To(Equal(name + "#1"))
+		}
+	})
+
+	It("returns snapshot of connections", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		Expect(ps.Listen(ctx, pubsub.Polls("1")).Wait(ctx)).To(Succeed())
+
+		connections := ps.Connections()
+		connections[0].Topics["polls.2"] = pubsub.TopicListening
+		connections[0].ID = 10
+		Expect(ps.Connections()[0].Topics).To(HaveLen(1))
+		Expect(ps.Connections()[0].ID).To(Equal(int64(0)))
+		Expect(ps.Connections()[0].Label).To(Equal("#0"))
+	})
+})
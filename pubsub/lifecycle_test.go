@@ -0,0 +1,98 @@
+package pubsub_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lifecycle", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	It("close connection many times", func() {
+		c := pubsub.NewConnection(server.URL())
+		Expect(c.Close()).To(Succeed())
+		Expect(c.Close()).To(Succeed())
+		Expect(c.Shutdown(ctx)).To(Succeed())
+	})
+
+	It("unlisten topics and handle queued messages on shutdown", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithDispatchPolicy(pubsub.DispatchPolicy{
+			Workers: 1,
+			Queue:   10,
+		}))
+		defer ps.Close()
+
+		var mu sync.Mutex
+		handled := 0
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			handled++
+		})
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("2")).Wait(ctx)).To(Succeed())
+		for i := 0; i < 5; i++ {
+			server.Publish("video-playback-by-id.1", fmt.Sprintf(`{"type":"viewcount","viewers":%d}`, i))
+		}
+
+		Expect(ps.Shutdown(ctx)).To(Succeed())
+		Expect(ps.Shutdown(ctx)).To(Succeed())
+		Expect(ps.TopicsCount()).To(BeZero())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(handled).To(Equal(5))
+
+		frames := server.Frames(pubsub.Unlisten)
+		Expect(frames).To(HaveLen(1))
+		Expect(frames[0].Data.Topics).To(ConsistOf("video-playback-by-id.1", "video-playback-by-id.2"))
+	})
+
+	It("return context error if handler is stuck", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		release := make(chan struct{})
+		defer close(release)
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			<-release
+		})
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+		time.Sleep(100 * time.Millisecond)
+
+		sctx, scancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer scancel()
+		Expect(ps.Shutdown(sctx)).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("run until context is done", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+
+		rctx, rcancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			done <- ps.Run(rctx)
+		}()
+
+		Consistently(done, 100*time.Millisecond).ShouldNot(Receive())
+		rcancel()
+		Eventually(done, 5*time.Second).Should(Receive(BeNil()))
+		Expect(server.Frames(pubsub.Unlisten)).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,92 @@
+package pubsub
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Twitch recommends not more than 10 simultaneous connections per client IP.
+//
+// https://dev.twitch.tv/docs/pubsub/#connection-management
+const TwitchApiMaxConnections = 10
+
+// LimitPolicy is represent of limits per client IP. Topics which can't be
+// placed because of limits are queued and will be listened later.
+// Zero values mean no limits.
+type LimitPolicy struct {
+	// Maximum count of connections
+	MaxConnections int
+
+	// Maximum count of LISTEN requests per Window, it's shared by all
+	// connections and counts requests after reconnect too
+	ListenRate int
+
+	// Time window of ListenRate
+	Window time.Duration
+}
+
+// limited returns true if any limit is set.
+func (p LimitPolicy) limited() bool {
+	return p.MaxConnections > 0 || p.rated()
+}
+
+func (p LimitPolicy) rated() bool {
+	return p.ListenRate > 0 && p.Window > 0
+}
+
+// limiter is count of LISTEN requests in time window. It's shared by
+// PubSub and its connections, so it has own mutex.
+type limiter struct {
+	sync.Mutex
+
+	policy LimitPolicy
+	sent   []time.Time
+}
+
+func newLimiter(policy LimitPolicy) *limiter {
+	return &limiter{policy: policy}
+}
+
+// available returns count of LISTEN requests which can be sent now.
+func (l *limiter) available(now time.Time) int {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.free(now)
+}
+
+// allow returns true and count LISTEN request if it can be sent now.
+func (l *limiter) allow(now time.Time) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.free(now) <= 0 {
+		return false
+	}
+	if l.policy.rated() {
+		l.sent = append(l.sent, now)
+	}
+	return true
+}
+
+// free is unguarded version of available.
+func (l *limiter) free(now time.Time) int {
+	if !l.policy.rated() {
+		return math.MaxInt
+	}
+
+	// Forget requests out of window
+	i := 0
+	for i < len(l.sent) && now.Sub(l.sent[i]) >= l.policy.Window {
+		i++
+	}
+	l.sent = l.sent[i:]
+
+	return l.policy.ListenRate - len(l.sent)
+}
+
+// connect returns true if new connection can be created.
+func (l *limiter) connect(count int) bool {
+	return l.policy.MaxConnections <= 0 || count < l.policy.MaxConnections
+}
@@ -0,0 +1,161 @@
+package pubsub_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LimitPolicy", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	It("queue topics over connections limit", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithLimitPolicy(pubsub.LimitPolicy{
+			MaxConnections: 1,
+		}))
+		defer ps.Close()
+
+		var mu sync.Mutex
+		queued := []string{}
+		ps.OnTopicQueued(func(topic string) {
+			mu.Lock()
+			defer mu.Unlock()
+			queued = append(queued, topic)
+		})
+
+		results := ps.ListenMany(ctx, videoTopics(1, 55)...)
+		for _, result := range results[:50] {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+		Expect(ps.TopicsCount()).To(Equal(50))
+
+		mu.Lock()
+		Expect(queued).To(HaveLen(5))
+		mu.Unlock()
+
+		states := ps.TopicStates()
+		Expect(states).To(HaveLen(55))
+		Expect(states["video-playback-by-id.55"]).To(Equal(pubsub.TopicQueued))
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("55")).Err()).To(MatchError(pubsub.ErrAlreadyListening))
+		Expect(ps.Unlisten(ctx, pubsub.VideoPlayback("55")).Wait(ctx)).To(Succeed())
+		Expect(results[54].Wait(ctx)).To(MatchError(pubsub.ErrUnlistened))
+
+		// Free space for queued topics
+		for _, result := range ps.UnlistenMany(ctx, videoTopics(1, 10)...) {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+		for _, result := range results[50:54] {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+		Expect(ps.TopicsCount()).To(Equal(44))
+		Expect(ps.Connections()).To(HaveLen(1))
+	})
+
+	It("limit LISTEN rate", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithLimitPolicy(pubsub.LimitPolicy{
+			ListenRate: 1,
+			Window:     200 * time.Millisecond,
+		}))
+		defer ps.Close()
+
+		// First LISTEN is sent on connect, wait for window
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		time.Sleep(200 * time.Millisecond)
+
+		start := time.Now()
+		results := []*pubsub.Result{}
+		for _, topic := range videoTopics(2, 3) {
+			results = append(results, ps.Listen(ctx, topic))
+		}
+		for _, result := range results {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+
+		// Queued topics are sent together
+		Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+		frames := server.Frames(pubsub.Listen)
+		Expect(frames).To(HaveLen(3))
+		Expect(frames[2].Data.Topics).To(Equal([]string{"video-playback-by-id.3", "video-playback-by-id.4"}))
+	})
+
+	It("limit LISTEN of isolated topics", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithLimitPolicy(pubsub.LimitPolicy{
+			ListenRate: 2,
+			Window:     200 * time.Millisecond,
+		}))
+		defer ps.Close()
+
+		server.SetReject(func(f frame) string {
+			for _, topic := range f.Data.Topics {
+				if topic == "video-playback-by-id.4" {
+					return "ERR_BADTOPIC"
+				}
+			}
+			return ""
+		})
+
+		// One batch and four separate requests
+		start := time.Now()
+		results := ps.ListenMany(ctx, videoTopics(1, 4)...)
+		for _, result := range results[:3] {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+		Expect(results[3].Wait(ctx)).To(MatchError(pubsub.ErrBadTopic))
+
+		Expect(server.Frames(pubsub.Listen)).To(HaveLen(5))
+		Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+	})
+
+	It("limit LISTEN on reconnect", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithLimitPolicy(pubsub.LimitPolicy{
+			ListenRate: 1,
+			Window:     200 * time.Millisecond,
+		}))
+		defer ps.Close()
+
+		Expect(ps.ListenWithAuth(ctx, "a", pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		Expect(ps.ListenWithAuth(ctx, "b", pubsub.VideoPlayback("2")).Wait(ctx)).To(Succeed())
+		Expect(server.Frames(pubsub.Listen)).To(HaveLen(2))
+
+		// Wait for window, two tokens are two requests
+		time.Sleep(200 * time.Millisecond)
+		server.DropConnections()
+
+		Eventually(func() int {
+			return len(server.Frames(pubsub.Listen))
+		}, 5*time.Second).Should(Equal(3))
+		Consistently(func() int {
+			return len(server.Frames(pubsub.Listen))
+		}, 100*time.Millisecond).Should(Equal(3))
+		Eventually(func() int {
+			return len(server.Frames(pubsub.Listen))
+		}, 5*time.Second).Should(Equal(4))
+		Eventually(func() map[string]pubsub.TopicState {
+			return ps.TopicStates()
+		}, 5*time.Second).Should(Equal(map[string]pubsub.TopicState{
+			"video-playback-by-id.1": pubsub.TopicListening,
+			"video-playback-by-id.2": pubsub.TopicListening,
+		}))
+	})
+
+	It("resolve queued topics on close", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithLimitPolicy(pubsub.LimitPolicy{
+			ListenRate: 1,
+			Window:     time.Hour,
+		}))
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		result := ps.Listen(ctx, pubsub.VideoPlayback("2"))
+
+		ps.Close()
+		Expect(result.Wait(ctx)).To(MatchError(pubsub.ErrClosed))
+	})
+})
@@ -0,0 +1,87 @@
+package pubsub_test
+
+import (
+	"context"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Many", func() {
+	var ctx context.Context
+	var server *fakeServer
+	var ps *pubsub.PubSub
+	withFakeServer(&ctx, &server)
+
+	// wait is wait all results and returns their errors
+	wait := func(results []*pubsub.Result) []error {
+		errs := []error{}
+		for _, result := range results {
+			errs = append(errs, result.Wait(ctx))
+		}
+		return errs
+	}
+
+	BeforeEach(func() {
+		ps = pubsub.NewWithURL(server.URL())
+	})
+
+	AfterEach(func() {
+		ps.Close()
+	})
+
+	It("listen many topics with one LISTEN per connection", func() {
+		for _, err := range wait(ps.ListenMany(ctx, videoTopics(1, 120)...)) {
+			Expect(err).To(Succeed())
+		}
+
+		Expect(ps.TopicsCount()).To(Equal(120))
+		Expect(ps.Connections()).To(HaveLen(3))
+
+		frames := server.Frames(pubsub.Listen)
+		Expect(frames).To(HaveLen(3))
+		for _, f := range frames {
+			Expect(len(f.Data.Topics)).To(BeNumerically("<=", pubsub.TwitchApiMaxTopics))
+		}
+	})
+
+	It("send only new topics", func() {
+		for _, err := range wait(ps.ListenMany(ctx, videoTopics(1, 2)...)) {
+			Expect(err).To(Succeed())
+		}
+
+		errs := wait(ps.ListenMany(ctx, pubsub.VideoPlayback("1"), pubsub.VideoPlayback("3"), pubsub.VideoPlayback("3"), "bad"))
+		Expect(errs[0]).To(MatchError(pubsub.ErrAlreadyListening))
+		Expect(errs[1]).To(Succeed())
+		Expect(errs[2]).To(MatchError(pubsub.ErrAlreadyListening))
+		Expect(errs[3]).To(MatchError(pubsub.ErrInvalidTopic))
+
+		frames := server.Frames(pubsub.Listen)
+		Expect(frames).To(HaveLen(2))
+		Expect(frames[1].Data.Topics).To(Equal([]string{"video-playback-by-id.3"}))
+	})
+
+	It("unlisten many topics and remove empty connections", func() {
+		for _, err := range wait(ps.ListenMany(ctx, videoTopics(1, 60)...)) {
+			Expect(err).To(Succeed())
+		}
+
+		for _, err := range wait(ps.UnlistenMany(ctx, videoTopics(1, 40)...)) {
+			Expect(err).To(Succeed())
+		}
+		Expect(ps.TopicsCount()).To(Equal(20))
+		Expect(ps.Connections()).To(HaveLen(2))
+
+		frames := server.Frames(pubsub.Unlisten)
+		Expect(frames).To(HaveLen(1))
+		Expect(frames[0].Data.Topics).To(HaveLen(40))
+
+		for _, err := range wait(ps.UnlistenMany(ctx, videoTopics(41, 20)...)) {
+			Expect(err).To(Succeed())
+		}
+		Expect(ps.TopicsCount()).To(BeZero())
+		Expect(ps.Connections()).To(BeEmpty())
+	})
+})
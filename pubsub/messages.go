@@ -0,0 +1,355 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// decoders is decode message payload by topic family.
+//
+// https://dev.twitch.tv/docs/pubsub/#topics
+var decoders = map[string]func(msg []byte) (any, error){
+	"channel-bits-events-v1":      decodeAs[BitsMessage],
+	FamilyBitsEvents:              decodeAs[BitsMessage],
+	FamilyBitsBadgeUnlocks:        decodeAs[BitsBadgeUnlockMessage],
+	FamilyChannelPoints:           decodeAs[ChannelPointsMessage],
+	"community-points-channel-v1": decodeAs[ChannelPointsMessage],
+	FamilyChannelSubscriptions:    decodeAs[SubscribeMessage],
+	FamilyAutoModQueue:            decodeAs[AutoModQueueMessage],
+	FamilyChatModeratorActions:    decodeAs[ModeratorActionMessage],
+	FamilyWhispers:                decodeAs[WhisperMessage],
+	FamilyVideoPlayback:           decodeAs[VideoPlaybackMessage],
+	FamilyPredictions:             decodeAs[PredictionMessage],
+	FamilyPolls:                   decodeAs[PollMessage],
+	FamilyHypeTrain:               decodeAs[HypeTrainMessage],
+}
+
+func decodeAs[T any](msg []byte) (any, error) {
+	var v T
+	if err := json.Unmarshal(msg, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Decode returns typed message payload by topic family, for example
+// *BitsMessage for channel-bits-events-v2 topic. For unknown topics it
+// returns raw AnswerDataMessage.
+func (a *Answer) Decode() (any, error) {
+	if a.Type != Message {
+		return nil, fmt.Errorf("pubsub: can't decode %s answer", a.Type)
+	}
+
+	a.Parse()
+	data := a.GetData()
+
+	decode, ok := decoders[Topic(data.Topic).Family()]
+	if !ok {
+		return data, nil
+	}
+
+	v, err := decode([]byte(data.Message))
+	if err != nil {
+		return nil, fmt.Errorf("pubsub: decode %s: %w", data.Topic, err)
+	}
+
+	return v, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// BitsMessage is payload of channel-bits-events-v1 and
+// channel-bits-events-v2 topics.
+type BitsMessage struct {
+	Data struct {
+		UserName         string    `json:"user_name"`
+		ChannelName      string    `json:"channel_name"`
+		UserID           string    `json:"user_id"`
+		ChannelID        string    `json:"channel_id"`
+		Time             time.Time `json:"time"`
+		ChatMessage      string    `json:"chat_message"`
+		BitsUsed         int       `json:"bits_used"`
+		TotalBitsUsed    int       `json:"total_bits_used"`
+		IsAnonymous      bool      `json:"is_anonymous"`
+		Context          string    `json:"context"`
+		BadgeEntitlement *struct {
+			NewVersion      int `json:"new_version"`
+			PreviousVersion int `json:"previous_version"`
+		} `json:"badge_entitlement"`
+	} `json:"data"`
+	Version     string `json:"version"`
+	MessageType string `json:"message_type"`
+	MessageID   string `json:"message_id"`
+}
+
+// BitsBadgeUnlockMessage is payload of channel-bits-badge-unlocks topic.
+type BitsBadgeUnlockMessage struct {
+	UserID      string    `json:"user_id"`
+	UserName    string    `json:"user_name"`
+	ChannelID   string    `json:"channel_id"`
+	ChannelName string    `json:"channel_name"`
+	BadgeTier   int       `json:"badge_tier"`
+	ChatMessage string    `json:"chat_message"`
+	Time        time.Time `json:"time"`
+}
+
+// ChannelPointsMessage is payload of channel-points-channel-v1 topic.
+type ChannelPointsMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Timestamp  time.Time `json:"timestamp"`
+		Redemption struct {
+			ID   string `json:"id"`
+			User struct {
+				ID          string `json:"id"`
+				Login       string `json:"login"`
+				DisplayName string `json:"display_name"`
+			} `json:"user"`
+			ChannelID  string    `json:"channel_id"`
+			RedeemedAt time.Time `json:"redeemed_at"`
+			Reward     struct {
+				ID                  string `json:"id"`
+				ChannelID           string `json:"channel_id"`
+				Title               string `json:"title"`
+				Prompt              string `json:"prompt"`
+				Cost                int    `json:"cost"`
+				IsUserInputRequired bool   `json:"is_user_input_required"`
+				IsSubOnly           bool   `json:"is_sub_only"`
+				BackgroundColor     string `json:"background_color"`
+				IsEnabled           bool   `json:"is_enabled"`
+				IsPaused            bool   `json:"is_paused"`
+				IsInStock           bool   `json:"is_in_stock"`
+				MaxPerStream        struct {
+					IsEnabled    bool `json:"is_enabled"`
+					MaxPerStream int  `json:"max_per_stream"`
+				} `json:"max_per_stream"`
+				ShouldRedemptionsSkipRequestQueue bool `json:"should_redemptions_skip_request_queue"`
+			} `json:"reward"`
+			UserInput string `json:"user_input"`
+			Status    string `json:"status"`
+		} `json:"redemption"`
+	} `json:"data"`
+}
+
+// SubscribeMessage is payload of channel-subscribe-events-v1 topic.
+type SubscribeMessage struct {
+	UserName             string    `json:"user_name"`
+	DisplayName          string    `json:"display_name"`
+	ChannelName          string    `json:"channel_name"`
+	UserID               string    `json:"user_id"`
+	ChannelID            string    `json:"channel_id"`
+	Time                 time.Time `json:"time"`
+	SubPlan              string    `json:"sub_plan"`
+	SubPlanName          string    `json:"sub_plan_name"`
+	CumulativeMonths     int       `json:"cumulative_months"`
+	StreakMonths         int       `json:"streak_months"`
+	Months               int       `json:"months"`
+	Context              string    `json:"context"`
+	IsGift               bool      `json:"is_gift"`
+	RecipientID          string    `json:"recipient_id"`
+	RecipientUserName    string    `json:"recipient_user_name"`
+	RecipientDisplayName string    `json:"recipient_display_name"`
+	MultiMonthDuration   int       `json:"multi_month_duration"`
+	SubMessage           struct {
+		Message string `json:"message"`
+		Emotes  []struct {
+			Start int `json:"start"`
+			End   int `json:"end"`
+			ID    int `json:"id"`
+		} `json:"emotes"`
+	} `json:"sub_message"`
+}
+
+// AutoModQueueMessage is payload of automod-queue topic.
+type AutoModQueueMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		ContentClassification struct {
+			Category string `json:"category"`
+			Level    int    `json:"level"`
+		} `json:"content_classification"`
+		Message struct {
+			Content struct {
+				Text      string `json:"text"`
+				Fragments []struct {
+					Text    string `json:"text"`
+					AutoMod struct {
+						Topics map[string]int `json:"topics"`
+					} `json:"automod"`
+				} `json:"fragments"`
+			} `json:"content"`
+			ID     string `json:"id"`
+			Sender struct {
+				UserID      string `json:"user_id"`
+				Login       string `json:"login"`
+				DisplayName string `json:"display_name"`
+				ChatColor   string `json:"chat_color"`
+			} `json:"sender"`
+			SentAt time.Time `json:"sent_at"`
+		} `json:"message"`
+		ReasonCode    string `json:"reason_code"`
+		ResolverID    string `json:"resolver_id"`
+		ResolverLogin string `json:"resolver_login"`
+		Status        string `json:"status"`
+	} `json:"data"`
+}
+
+// ModeratorActionMessage is payload of chat_moderator_actions topic.
+type ModeratorActionMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Type             string   `json:"type"`
+		ModerationAction string   `json:"moderation_action"`
+		Args             []string `json:"args"`
+		CreatedBy        string   `json:"created_by"`
+		CreatedByUserID  string   `json:"created_by_user_id"`
+		MsgID            string   `json:"msg_id"`
+		TargetUserID     string   `json:"target_user_id"`
+		TargetUserLogin  string   `json:"target_user_login"`
+		FromAutoMod      bool     `json:"from_automod"`
+	} `json:"data"`
+}
+
+// WhisperMessage is payload of whispers topic.
+type WhisperMessage struct {
+	Type       string `json:"type"`
+	DataObject struct {
+		MessageID string `json:"message_id"`
+		ID        int    `json:"id"`
+		ThreadID  string `json:"thread_id"`
+		Body      string `json:"body"`
+		SentTs    int64  `json:"sent_ts"`
+		FromID    int    `json:"from_id"`
+		Tags      struct {
+			Login       string `json:"login"`
+			DisplayName string `json:"display_name"`
+			Color       string `json:"color"`
+			Emotes      []struct {
+				EmoteID string `json:"emote_id"`
+				Start   int    `json:"start"`
+				End     int    `json:"end"`
+			} `json:"emotes"`
+			Badges []struct {
+				ID      string `json:"id"`
+				Version string `json:"version"`
+			} `json:"badges"`
+		} `json:"tags"`
+		Recipient struct {
+			ID           int    `json:"id"`
+			Username     string `json:"username"`
+			DisplayName  string `json:"display_name"`
+			Color        string `json:"color"`
+			ProfileImage string `json:"profile_image"`
+		} `json:"recipient"`
+		Nonce string `json:"nonce"`
+	} `json:"data_object"`
+}
+
+// VideoPlaybackMessage is payload of video-playback-by-id topic.
+type VideoPlaybackMessage struct {
+	Type       string  `json:"type"`
+	ServerTime float64 `json:"server_time"`
+	Viewers    int     `json:"viewers"`
+	PlayDelay  int     `json:"play_delay"`
+}
+
+// PredictionMessage is payload of predictions-channel-v1 topic.
+type PredictionMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Timestamp time.Time `json:"timestamp"`
+		Event     struct {
+			ID        string    `json:"id"`
+			ChannelID string    `json:"channel_id"`
+			CreatedAt time.Time `json:"created_at"`
+			CreatedBy struct {
+				Type            string `json:"type"`
+				UserID          string `json:"user_id"`
+				UserDisplayName string `json:"user_display_name"`
+			} `json:"created_by"`
+			EndedAt  *time.Time `json:"ended_at"`
+			LockedAt *time.Time `json:"locked_at"`
+			Outcomes []struct {
+				ID          string `json:"id"`
+				Color       string `json:"color"`
+				Title       string `json:"title"`
+				TotalPoints int    `json:"total_points"`
+				TotalUsers  int    `json:"total_users"`
+			} `json:"outcomes"`
+			PredictionWindowSeconds int    `json:"prediction_window_seconds"`
+			Status                  string `json:"status"`
+			Title                   string `json:"title"`
+			WinningOutcomeID        string `json:"winning_outcome_id"`
+		} `json:"event"`
+	} `json:"data"`
+}
+
+// PollMessage is payload of polls topic.
+type PollMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Poll struct {
+			PollID          string     `json:"poll_id"`
+			OwnedBy         string     `json:"owned_by"`
+			CreatedBy       string     `json:"created_by"`
+			Title           string     `json:"title"`
+			StartedAt       time.Time  `json:"started_at"`
+			EndedAt         *time.Time `json:"ended_at"`
+			EndedBy         string     `json:"ended_by"`
+			DurationSeconds int        `json:"duration_seconds"`
+			Status          string     `json:"status"`
+			Choices         []struct {
+				ChoiceID    string    `json:"choice_id"`
+				Title       string    `json:"title"`
+				Votes       PollVotes `json:"votes"`
+				TotalVoters int       `json:"total_voters"`
+			} `json:"choices"`
+			Votes                         PollVotes `json:"votes"`
+			TotalVoters                   int       `json:"total_voters"`
+			RemainingDurationMilliseconds int       `json:"remaining_duration_milliseconds"`
+		} `json:"poll"`
+	} `json:"data"`
+}
+
+// PollVotes is votes counters of poll or poll choice.
+type PollVotes struct {
+	Total         int `json:"total"`
+	Bits          int `json:"bits"`
+	ChannelPoints int `json:"channel_points"`
+	Base          int `json:"base"`
+}
+
+// HypeTrainMessage is payload of hype-train-events-v1 topic.
+// Data fields are filled depending on message type.
+type HypeTrainMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		ChannelID       string         `json:"channel_id"`
+		ID              string         `json:"id"`
+		StartedAt       int64          `json:"started_at"`
+		ExpiresAt       int64          `json:"expires_at"`
+		UpdatedAt       int64          `json:"updated_at"`
+		EndedAt         int64          `json:"ended_at"`
+		EndingReason    string         `json:"ending_reason"`
+		Participations  map[string]int `json:"participations"`
+		UserID          string         `json:"user_id"`
+		UserLogin       string         `json:"user_login"`
+		UserDisplayName string         `json:"user_display_name"`
+		SequenceID      int            `json:"sequence_id"`
+		Action          string         `json:"action"`
+		Source          string         `json:"source"`
+		Quantity        int            `json:"quantity"`
+		TimeToExpire    int64          `json:"time_to_expire"`
+		Progress        *struct {
+			Level struct {
+				Value int `json:"value"`
+				Goal  int `json:"goal"`
+			} `json:"level"`
+			Value            int `json:"value"`
+			Goal             int `json:"goal"`
+			Total            int `json:"total"`
+			RemainingSeconds int `json:"remaining_seconds"`
+		} `json:"progress"`
+	} `json:"data"`
+}
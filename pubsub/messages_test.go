@@ -0,0 +1,195 @@
+package pubsub_test
+
+import (
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Messages", func() {
+	message := func(topic, msg string) *pubsub.Answer {
+		return &pubsub.Answer{
+			Type: pubsub.Message,
+			Data: map[string]any{"topic": topic, "message": msg},
+		}
+	}
+
+	Context("Decode", func() {
+		It("decode bits message", func() {
+			v, err := message("channel-bits-events-v2.46024993", `{"data":{"user_name":"jwp","channel_name":"bontakun","user_id":"95546976","channel_id":"46024993","time":"2017-02-09T13:23:58.168Z","chat_message":"cheer10000 New badge hype!","bits_used":10000,"total_bits_used":25000,"is_anonymous":false,"context":"cheer","badge_entitlement":{"new_version":25000,"previous_version":10000}},"version":"1.0","message_type":"bits_event","message_id":"8145728a4-35f0-4cf7-9dc0-f2ef24de1eb6"}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.BitsMessage{}))
+
+			msg := v.(*pubsub.BitsMessage)
+			Expect(msg.Data.UserName).To(Equal("jwp"))
+			Expect(msg.Data.BitsUsed).To(Equal(10000))
+			Expect(msg.Data.BadgeEntitlement.NewVersion).To(Equal(25000))
+			Expect(msg.MessageType).To(Equal("bits_event"))
+		})
+
+		It("decode channel points message", func() {
+			v, err := message("channel-points-channel-v1.30515034", `{"type":"reward-redeemed","data":{"timestamp":"2019-11-12T01:29:34.98329743Z","redemption":{"id":"9203c6f0-51b6-4d1d-a9ae-8eafdb0d6d47","user":{"id":"30515034","login":"davethecust","display_name":"davethecust"},"channel_id":"30515034","redeemed_at":"2019-12-11T18:52:53.128421623Z","reward":{"id":"6ef17bb2-e5ae-432e-8b3f-5ac4dd774668","channel_id":"30515034","title":"hit a gleesh walk on stream","prompt":"cleanside's finest","cost":10,"is_user_input_required":true,"is_sub_only":false},"user_input":"yeooo","status":"FULFILLED"}}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.ChannelPointsMessage{}))
+
+			msg := v.(*pubsub.ChannelPointsMessage)
+			Expect(msg.Type).To(Equal("reward-redeemed"))
+			Expect(msg.Data.Redemption.User.Login).To(Equal("davethecust"))
+			Expect(msg.Data.Redemption.Reward.Cost).To(Equal(10))
+			Expect(msg.Data.Redemption.UserInput).To(Equal("yeooo"))
+		})
+
+		It("decode video playback message", func() {
+			v, err := message("video-playback-by-id.123", `{"type":"viewcount","server_time":1673456789.123,"viewers":42}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(Equal(&pubsub.VideoPlaybackMessage{
+				Type:       "viewcount",
+				ServerTime: 1673456789.123,
+				Viewers:    42,
+			}))
+		})
+
+		It("decode bits badge unlock message", func() {
+			v, err := message("channel-bits-badge-unlocks.232889822", `{"user_id":"232889822","user_name":"willowolf","channel_id":"232889822","channel_name":"willowolf","badge_tier":1000,"chat_message":"this should be received by the public pubsub listener","time":"2020-12-06T00:01:43.71253159Z"}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.BitsBadgeUnlockMessage{}))
+
+			msg := v.(*pubsub.BitsBadgeUnlockMessage)
+			Expect(msg.UserName).To(Equal("willowolf"))
+			Expect(msg.BadgeTier).To(Equal(1000))
+			Expect(msg.Time.Year()).To(Equal(2020))
+		})
+
+		It("decode subscribe message", func() {
+			v, err := message("channel-subscribe-events-v1.89614178", `{"user_name":"tww2","display_name":"TWW2","channel_name":"mr_woodchuck","user_id":"13405587","channel_id":"89614178","time":"2015-12-19T16:39:57-08:00","sub_plan":"1000","sub_plan_name":"Channel Subscription (mr_woodchuck)","cumulative_months":9,"streak_months":3,"context":"resub","is_gift":false,"sub_message":{"message":"A Twitch baby is born! KappaHD","emotes":[{"start":23,"end":7,"id":2867}]}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.SubscribeMessage{}))
+
+			msg := v.(*pubsub.SubscribeMessage)
+			Expect(msg.DisplayName).To(Equal("TWW2"))
+			Expect(msg.CumulativeMonths).To(Equal(9))
+			Expect(msg.Context).To(Equal("resub"))
+			Expect(msg.SubMessage.Emotes).To(HaveLen(1))
+			Expect(msg.SubMessage.Emotes[0].ID).To(Equal(2867))
+		})
+
+		It("decode gift subscribe message", func() {
+			v, err := message("channel-subscribe-events-v1.89614178", `{"user_name":"tww2","display_name":"TWW2","channel_name":"mr_woodchuck","user_id":"13405587","channel_id":"89614178","time":"2015-12-19T16:39:57-08:00","sub_plan":"1000","sub_plan_name":"Channel Subscription (mr_woodchuck)","months":9,"context":"subgift","is_gift":true,"sub_message":{"message":"","emotes":null},"recipient_id":"19571752","recipient_user_name":"forstycup","recipient_display_name":"forstycup","multi_month_duration":6}`).Decode()
+			Expect(err).To(Succeed())
+
+			msg := v.(*pubsub.SubscribeMessage)
+			Expect(msg.IsGift).To(BeTrue())
+			Expect(msg.Months).To(Equal(9))
+			Expect(msg.RecipientUserName).To(Equal("forstycup"))
+			Expect(msg.MultiMonthDuration).To(Equal(6))
+		})
+
+		It("decode automod queue message", func() {
+			v, err := message("automod-queue.44322889.44322889", `{"type":"automod_caught_message","data":{"content_classification":{"category":"aggressive","level":1},"message":{"content":{"text":"sample message","fragments":[{"text":"sample message","automod":{"topics":{"bullying":4}}}]},"id":"4b8d2f4c-7ee4-4a8e-a8c3-4d4e3c6b1a21","sender":{"user_id":"44322889","login":"dallas","display_name":"dallas","chat_color":"#8A2BE2"},"sent_at":"2022-01-24T20:20:26.170219288Z"},"reason_code":"","resolver_id":"","resolver_login":"","status":"PENDING"}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.AutoModQueueMessage{}))
+
+			msg := v.(*pubsub.AutoModQueueMessage)
+			Expect(msg.Type).To(Equal("automod_caught_message"))
+			Expect(msg.Data.ContentClassification.Level).To(Equal(1))
+			Expect(msg.Data.Message.Content.Fragments[0].AutoMod.Topics).To(HaveKeyWithValue("bullying", 4))
+			Expect(msg.Data.Message.Sender.Login).To(Equal("dallas"))
+			Expect(msg.Data.Status).To(Equal("PENDING"))
+		})
+
+		It("decode moderator action message", func() {
+			v, err := message("chat_moderator_actions.44322889.44322889", `{"type":"moderation_action","data":{"type":"chat_login_moderation","moderation_action":"ban","args":["baduser","spam"],"created_by":"dallas","created_by_user_id":"44322889","msg_id":"","target_user_id":"129454141","target_user_login":"","from_automod":false}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.ModeratorActionMessage{}))
+
+			msg := v.(*pubsub.ModeratorActionMessage)
+			Expect(msg.Type).To(Equal("moderation_action"))
+			Expect(msg.Data.ModerationAction).To(Equal("ban"))
+			Expect(msg.Data.Args).To(Equal([]string{"baduser", "spam"}))
+			Expect(msg.Data.TargetUserID).To(Equal("129454141"))
+		})
+
+		It("decode whisper message", func() {
+			v, err := message("whispers.44322889", `{"type":"whisper_received","data":"{\"id\":41}","data_object":{"message_id":"552c9bb6-cc7d-4a5b-9d44-2bbb4b1b3d3e","id":41,"thread_id":"129454141_44322889","body":"hello","sent_ts":1479160009,"from_id":39141793,"tags":{"login":"dallas","display_name":"dallas","color":"#8A2BE2","emotes":[],"badges":[{"id":"staff","version":"1"}]},"recipient":{"id":129454141,"username":"dallasnchains","display_name":"dallasnchains","color":"","profile_image":null},"nonce":"6GVBTfBXNj7d71BULYKjpiKapegDI1"}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.WhisperMessage{}))
+
+			msg := v.(*pubsub.WhisperMessage)
+			Expect(msg.Type).To(Equal("whisper_received"))
+			Expect(msg.DataObject.ID).To(Equal(41))
+			Expect(msg.DataObject.Body).To(Equal("hello"))
+			Expect(msg.DataObject.FromID).To(Equal(39141793))
+			Expect(msg.DataObject.Tags.Badges[0].ID).To(Equal("staff"))
+			Expect(msg.DataObject.Recipient.Username).To(Equal("dallasnchains"))
+		})
+
+		It("decode prediction message", func() {
+			v, err := message("predictions-channel-v1.44322889", `{"type":"event-created","data":{"timestamp":"2021-05-04T19:45:41.213346558Z","event":{"id":"d1a3a9a4-0c0f-4b3a-9b0e-6f3b9b1d6a11","channel_id":"44322889","created_at":"2021-05-04T19:45:41.160447318Z","created_by":{"type":"USER","user_id":"44322889","user_display_name":"dallas","extension_client_id":null},"ended_at":null,"ended_by":null,"locked_at":null,"locked_by":null,"outcomes":[{"id":"9e3c5a2b-6a0b-4b8f-8d0d-7c1c1e1f2a01","color":"BLUE","title":"Yes","total_points":0,"total_users":0,"top_predictors":[],"badge":{"version":"blue-1","set_id":"predictions"}},{"id":"9e3c5a2b-6a0b-4b8f-8d0d-7c1c1e1f2a02","color":"PINK","title":"No","total_points":0,"total_users":0,"top_predictors":[],"badge":{"version":"pink-2","set_id":"predictions"}}],"prediction_window_seconds":600,"status":"ACTIVE","title":"Will it work?","winning_outcome_id":null}}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.PredictionMessage{}))
+
+			msg := v.(*pubsub.PredictionMessage)
+			Expect(msg.Type).To(Equal("event-created"))
+			Expect(msg.Data.Event.CreatedBy.UserDisplayName).To(Equal("dallas"))
+			Expect(msg.Data.Event.EndedAt).To(BeNil())
+			Expect(msg.Data.Event.Outcomes).To(HaveLen(2))
+			Expect(msg.Data.Event.PredictionWindowSeconds).To(Equal(600))
+		})
+
+		It("decode poll message", func() {
+			v, err := message("polls.44322889", `{"type":"POLL_CREATE","data":{"poll":{"poll_id":"6fbcb6d0-f9f2-4d5a-8a2f-0b6c6a6a5c01","owned_by":"44322889","created_by":"44322889","title":"Best game?","started_at":"2021-03-19T04:27:29.546416574Z","ended_at":null,"ended_by":null,"duration_seconds":60,"settings":{"multi_vote":{"is_enabled":false},"subscriber_only":{"is_enabled":false},"subscriber_multiplier":{"is_enabled":false},"bits_votes":{"is_enabled":false,"cost":0},"channel_points_votes":{"is_enabled":false,"cost":0}},"status":"ACTIVE","choices":[{"choice_id":"6fbcb6d0-f9f2-4d5a-8a2f-0b6c6a6a5c02","title":"Chess","votes":{"total":0,"bits":0,"channel_points":0,"base":0},"tokens":{"bits":0,"channel_points":0},"total_voters":0}],"votes":{"total":0,"bits":0,"channel_points":0,"base":0},"tokens":{"bits":0,"channel_points":0},"total_voters":0,"remaining_duration_milliseconds":60000,"top_contributor":null,"top_bits_contributor":null,"top_channel_points_contributor":null}}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.PollMessage{}))
+
+			msg := v.(*pubsub.PollMessage)
+			Expect(msg.Type).To(Equal("POLL_CREATE"))
+			Expect(msg.Data.Poll.Title).To(Equal("Best game?"))
+			Expect(msg.Data.Poll.EndedAt).To(BeNil())
+			Expect(msg.Data.Poll.Choices[0].Title).To(Equal("Chess"))
+			Expect(msg.Data.Poll.RemainingDurationMilliseconds).To(Equal(60000))
+		})
+
+		It("decode hype train message", func() {
+			v, err := message("hype-train-events-v1.44322889", `{"type":"hype-train-progression","data":{"user_id":"129454141","user_login":"dallasnchains","user_display_name":"dallasnchains","sequence_id":5000,"action":"CHEER","source":"BITS","quantity":100,"progress":{"level":{"value":1,"goal":1600,"rewards":[]},"value":100,"goal":1600,"total":100,"remaining_seconds":295}}}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(BeAssignableToTypeOf(&pubsub.HypeTrainMessage{}))
+
+			msg := v.(*pubsub.HypeTrainMessage)
+			Expect(msg.Type).To(Equal("hype-train-progression"))
+			Expect(msg.Data.Source).To(Equal("BITS"))
+			Expect(msg.Data.Quantity).To(Equal(100))
+			Expect(msg.Data.Progress.Level.Goal).To(Equal(1600))
+			Expect(msg.Data.Progress.RemainingSeconds).To(Equal(295))
+		})
+
+		It("decode hype train start message", func() {
+			v, err := message("hype-train-events-v1.44322889", `{"type":"hype-train-start","data":{"channel_id":"44322889","id":"0f5b3a1e-5b9c-4a57-9d1a-2b8a6d2f4c01","started_at":1603127828000,"expires_at":1603128128000,"updated_at":1603127828000,"ended_at":null,"ending_reason":null,"participations":{},"progress":{"level":{"value":1,"goal":1600,"rewards":[]},"value":0,"goal":1600,"total":0,"remaining_seconds":299}}}`).Decode()
+			Expect(err).To(Succeed())
+
+			msg := v.(*pubsub.HypeTrainMessage)
+			Expect(msg.Type).To(Equal("hype-train-start"))
+			Expect(msg.Data.StartedAt).To(Equal(int64(1603127828000)))
+			Expect(msg.Data.EndingReason).To(BeEmpty())
+		})
+
+		It("return raw payload for unknown topic", func() {
+			v, err := message("unknown-topic.123", `{"a":1}`).Decode()
+			Expect(err).To(Succeed())
+			Expect(v).To(Equal(pubsub.AnswerDataMessage{
+				Topic:   "unknown-topic.123",
+				Message: `{"a":1}`,
+			}))
+		})
+
+		It("return error for bad payload", func() {
+			_, err := message("whispers.123", `{"type":`).Decode()
+			Expect(err).To(MatchError(HavePrefix("pubsub: decode whispers.123: ")))
+		})
+
+		It("return error for not message", func() {
+			_, err := (&pubsub.Answer{Type: pubsub.Pong}).Decode()
+			Expect(err).To(MatchError(HavePrefix("pubsub: ")))
+		})
+	})
+})
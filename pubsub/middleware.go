@@ -0,0 +1,106 @@
+package pubsub
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Middleware is wrap message handler, it can be used for logging, metrics,
+// filtering and so on. Middleware must call next handler to pass message.
+type Middleware func(next MessageHandler) MessageHandler
+
+// Chain wraps handler by middlewares, first middleware will be called first.
+func Chain(fn MessageHandler, middlewares ...Middleware) MessageHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](fn)
+	}
+	return fn
+}
+
+// Recover is recover panic of next handlers and fire OnError event instead.
+func Recover() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(c *Connection, msg *Answer) {
+			if err := catch(EventMessage, func() { next(c, msg) }); err != nil && c != nil {
+				c.onError(err)
+			}
+		}
+	}
+}
+
+// Logger is log every message and its handling time.
+// Standard logger will be used if logger is nil.
+func Logger(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next MessageHandler) MessageHandler {
+		return func(c *Connection, msg *Answer) {
+			start := time.Now()
+			next(c, msg)
+
+			label := "#0"
+			if c != nil {
+				label = c.Label()
+			}
+			logger.Printf("message (%s), topic: %s, took: %s\n", label, msg.GetData().Topic, time.Since(start))
+		}
+	}
+}
+
+// Dedupe is skip message if same message of same topic was in last size
+// messages. Twitch can send same message twice, for example after reconnect.
+func Dedupe(size int) Middleware {
+	if size < 1 {
+		size = 1
+	}
+
+	var mu sync.Mutex
+	seen := map[string]struct{}{}
+	keys := make([]string, 0, size)
+
+	return func(next MessageHandler) MessageHandler {
+		return func(c *Connection, msg *Answer) {
+			data := msg.GetData()
+			key := data.Topic + "\n" + data.Message
+
+			mu.Lock()
+			if _, ok := seen[key]; ok {
+				mu.Unlock()
+				return
+			}
+			if len(keys) >= size {
+				delete(seen, keys[0])
+				keys = keys[1:]
+			}
+			keys = append(keys, key)
+			seen[key] = struct{}{}
+			mu.Unlock()
+
+			next(c, msg)
+		}
+	}
+}
+
+// Filter is pass message to next handlers only if fn returns true.
+func Filter(fn func(*Connection, *Answer) bool) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(c *Connection, msg *Answer) {
+			if fn(c, msg) {
+				next(c, msg)
+			}
+		}
+	}
+}
+
+// Timing is report handling time of every message, it can be used for metrics.
+func Timing(fn func(c *Connection, msg *Answer, took time.Duration)) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(c *Connection, msg *Answer) {
+			start := time.Now()
+			next(c, msg)
+			fn(c, msg, time.Since(start))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package pubsub_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	// message returns parsed message of topic
+	message := func(topic, data string) *pubsub.Answer {
+		msg := &pubsub.Answer{
+			Type: pubsub.Message,
+			Data: map[string]any{"topic": topic, "message": data},
+		}
+		msg.Parse()
+		return msg
+	}
+
+	var calls []string
+
+	handler := func(c *pubsub.Connection, msg *pubsub.Answer) {
+		calls = append(calls, msg.GetData().Message)
+	}
+
+	// named returns middleware which record its name
+	named := func(name string) pubsub.Middleware {
+		return func(next pubsub.MessageHandler) pubsub.MessageHandler {
+			return func(c *pubsub.Connection, msg *pubsub.Answer) {
+				calls = append(calls, name)
+				next(c, msg)
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		calls = []string{}
+	})
+
+	It("call middlewares in order", func() {
+		pubsub.Chain(handler, named("first"), named("second"))(nil, message("polls.1", "msg"))
+		Expect(calls).To(Equal([]string{"first", "second", "msg"}))
+	})
+
+	It("skip duplicated messages", func() {
+		fn := pubsub.Chain(handler, pubsub.Dedupe(2))
+		fn(nil, message("polls.1", "1"))
+		fn(nil, message("polls.1", "1"))
+		fn(nil, message("polls.2", "1"))
+		fn(nil, message("polls.1", "2"))
+		fn(nil, message("polls.1", "1"))
+		Expect(calls).To(Equal([]string{"1", "1", "2", "1"}))
+	})
+
+	It("filter messages", func() {
+		fn := pubsub.Chain(handler, pubsub.Filter(func(c *pubsub.Connection, msg *pubsub.Answer) bool {
+			return msg.GetData().Topic == "polls.1"
+		}))
+		fn(nil, message("polls.1", "1"))
+		fn(nil, message("polls.2", "2"))
+		Expect(calls).To(Equal([]string{"1"}))
+	})
+
+	It("report handling time", func() {
+		var took time.Duration = -1
+		pubsub.Chain(handler, pubsub.Timing(func(c *pubsub.Connection, msg *pubsub.Answer, d time.Duration) {
+			took = d
+		}))(nil, message("polls.1", "1"))
+		Expect(took).To(BeNumerically(">=", 0))
+	})
+
+	It("log messages", func() {
+		var buf bytes.Buffer
+		pubsub.Chain(handler, pubsub.Logger(log.New(&buf, "", 0)))(nil, message("polls.1", "1"))
+		Expect(buf.String()).To(ContainSubstring("topic: polls.1"))
+	})
+
+	It("apply to all connections and recover panics", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithMiddleware(pubsub.Recover()))
+		defer ps.Close()
+
+		errs := make(chan error, 1)
+		ps.OnError(func(c *pubsub.Connection, err error) {
+			errs <- err
+		})
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			panic("boom")
+		})
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+
+		topics := make(chan string, 1)
+		ps.Use(func(next pubsub.MessageHandler) pubsub.MessageHandler {
+			return func(c *pubsub.Connection, msg *pubsub.Answer) {
+				topics <- msg.GetData().Topic
+				next(c, msg)
+			}
+		})
+
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+
+		Eventually(topics, 5*time.Second).Should(Receive(Equal("video-playback-by-id.1")))
+		Eventually(errs, 5*time.Second).Should(Receive(MatchError(ContainSubstring("boom"))))
+	})
+})
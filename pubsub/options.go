@@ -0,0 +1,138 @@
+package pubsub
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Option is configure PubSub and all its connections.
+type Option func(*options)
+
+type options struct {
+	dialer      *websocket.Dialer
+	header      http.Header
+	reconnect   ReconnectPolicy
+	ping        PingPolicy
+	middlewares []Middleware
+	dispatch    DispatchPolicy
+	compact     time.Duration
+	limit       LimitPolicy
+	placement   Placement
+	label       string
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		dialer:    websocket.DefaultDialer,
+		header:    http.Header{},
+		reconnect: DefaultReconnectPolicy,
+		ping:      DefaultPingPolicy,
+		placement: FillFirst{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithDialer sets websocket dialer. It can be used for proxy, TLS config
+// or handshake timeout.
+func WithDialer(dialer *websocket.Dialer) Option {
+	return func(o *options) {
+		if dialer != nil {
+			o.dialer = dialer
+		}
+	}
+}
+
+// WithHeader adds HTTP headers to every handshake request.
+func WithHeader(header http.Header) Option {
+	return func(o *options) {
+		for name, values := range header {
+			for _, value := range values {
+				o.header.Add(name, value)
+			}
+		}
+	}
+}
+
+// WithUserAgent sets User-Agent header of handshake request.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.header.Set("User-Agent", userAgent)
+	}
+}
+
+// WithReconnectPolicy sets delays between reconnect attempts.
+func WithReconnectPolicy(policy ReconnectPolicy) Option {
+	return func(o *options) {
+		o.reconnect = policy
+	}
+}
+
+// WithPingPolicy sets ping interval and PONG timeout.
+func WithPingPolicy(policy PingPolicy) Option {
+	return func(o *options) {
+		o.ping = policy
+	}
+}
+
+// WithMiddleware adds middlewares around messages handlers.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithDispatchPolicy sets asynchronous messages dispatching.
+// It can't be changed for existing connections.
+func WithDispatchPolicy(policy DispatchPolicy) Option {
+	return func(o *options) {
+		o.dispatch = policy
+	}
+}
+
+// WithCompactInterval enables periodic compaction of connections,
+// see PubSub.Compact. Zero means compaction only on demand.
+func WithCompactInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.compact = interval
+	}
+}
+
+// WithLimitPolicy sets maximum connections and LISTEN requests rate.
+func WithLimitPolicy(policy LimitPolicy) Option {
+	return func(o *options) {
+		o.limit = policy
+	}
+}
+
+// WithPlacement sets how topics are placed to connections.
+// Default is FillFirst.
+func WithPlacement(placement Placement) Option {
+	return func(o *options) {
+		if placement != nil {
+			o.placement = placement
+		}
+	}
+}
+
+// WithLabel sets human-readable name of connections, it's used
+// in logs together with connection ID, see Connection.Label.
+func WithLabel(label string) Option {
+	return func(o *options) {
+		o.label = label
+	}
+}
+
+// withOptions sets all options at once, used to pass
+// PubSub options to connections.
+func withOptions(opts options) Option {
+	return func(o *options) {
+		*o = opts
+		o.header = opts.header.Clone()
+		o.middlewares = append([]Middleware{}, opts.middlewares...)
+	}
+}
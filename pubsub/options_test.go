@@ -0,0 +1,52 @@
+package pubsub_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Options", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	It("apply dialer and headers to every connection", func() {
+		var dials int64
+		dialer := &websocket.Dialer{
+			HandshakeTimeout: 5 * time.Second,
+			NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				atomic.AddInt64(&dials, 1)
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			},
+		}
+
+		ps := pubsub.NewWithURL(
+			server.URL(),
+			pubsub.WithDialer(dialer),
+			pubsub.WithUserAgent("golang-twitch-test"),
+			pubsub.WithHeader(http.Header{"X-Test": {"1"}}),
+		)
+		defer ps.Close()
+
+		for _, topic := range videoTopics(1, 51) {
+			ps.Listen(ctx, topic)
+		}
+		Expect(len(ps.Connections())).To(Equal(2))
+
+		Eventually(server.Headers, 5*time.Second).Should(HaveLen(2))
+		for _, header := range server.Headers() {
+			Expect(header.Get("User-Agent")).To(Equal("golang-twitch-test"))
+			Expect(header.Get("X-Test")).To(Equal("1"))
+		}
+		Expect(atomic.LoadInt64(&dials)).To(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,33 @@
+package pubsub
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned by OnError event when bound func is panicked.
+// Connection goroutines will continue to work.
+type PanicError struct {
+	Kind  EventKind
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("pubsub: panic in %s handler: %v", e.Kind, e.Value)
+}
+
+// catch is call fn and returns panic as error.
+func catch(kind EventKind, fn func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{
+				Kind:  kind,
+				Value: v,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	fn()
+	return nil
+}
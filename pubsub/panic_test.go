@@ -0,0 +1,133 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Panic", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	It("recover panic of connection funcs and keep working", func() {
+		c := pubsub.NewConnection(server.URL())
+		defer c.Close()
+
+		errs := make(chan error, 10)
+		c.OnError(func(c *pubsub.Connection, err error) {
+			errs <- err
+		})
+		c.OnError(func(c *pubsub.Connection, err error) {
+			panic("ignored")
+		})
+		c.OnConnect(func(c *pubsub.Connection) {
+			panic("connect")
+		})
+		messages := make(chan string, 10)
+		c.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			messages <- msg.GetData().Message
+			panic("message")
+		})
+
+		Expect(c.AddTopic("video-playback-by-id.1").Wait(ctx)).To(Succeed())
+
+		var err error
+		var perr *pubsub.PanicError
+		Eventually(errs, 5*time.Second).Should(Receive(&err))
+		Expect(errors.As(err, &perr)).To(BeTrue())
+		Expect(perr.Kind).To(Equal(pubsub.EventConnect))
+		Expect(perr.Value).To(Equal("connect"))
+		Expect(string(perr.Stack)).To(ContainSubstring("panic_test.go"))
+
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":2}`)
+
+		Eventually(messages, 5*time.Second).Should(Receive(Equal(`{"type":"viewcount","viewers":1}`)))
+		Eventually(messages, 5*time.Second).Should(Receive(Equal(`{"type":"viewcount","viewers":2}`)))
+		Eventually(errs, 5*time.Second).Should(Receive(MatchError(ContainSubstring("panic in message handler"))))
+	})
+
+	It("call other PubSub funcs when one is panicked", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			panic("first")
+		})
+		messages := make(chan string, 1)
+		ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			messages <- msg.GetData().Topic
+		})
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+
+		Eventually(messages, 5*time.Second).Should(Receive(Equal("video-playback-by-id.1")))
+	})
+
+	It("call next matched route when one is panicked", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		errs := make(chan error, 10)
+		ps.OnError(func(c *pubsub.Connection, err error) {
+			errs <- err
+		})
+		ps.Handle("video-playback-by-id", func(c *pubsub.Connection, msg *pubsub.Answer) {
+			panic("first route")
+		})
+		messages := make(chan string, 1)
+		ps.Handle("video-playback-by-id.*", func(c *pubsub.Connection, msg *pubsub.Answer) {
+			messages <- msg.GetData().Topic
+		})
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+
+		Eventually(messages, 5*time.Second).Should(Receive(Equal("video-playback-by-id.1")))
+		Eventually(errs, 5*time.Second).Should(Receive(MatchError(ContainSubstring("first route"))))
+	})
+
+	It("report panic of PubSub funcs without connection", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithLimitPolicy(pubsub.LimitPolicy{
+			ListenRate: 1,
+			Window:     time.Hour,
+		}))
+		defer ps.Close()
+
+		ps.OnTopicQueued(func(topic string) {
+			panic("queued")
+		})
+		events := ps.Events(ctx)
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		ps.Listen(ctx, pubsub.VideoPlayback("2"))
+
+		var e pubsub.ErrorEvent
+		Eventually(func() bool {
+			for {
+				select {
+				case event := <-events:
+					if err, ok := event.(pubsub.ErrorEvent); ok {
+						e = err
+						return true
+					}
+				default:
+					return false
+				}
+			}
+		}, 5*time.Second).Should(BeTrue())
+
+		Expect(e.Connection).To(BeNil())
+		var perr *pubsub.PanicError
+		Expect(errors.As(e.Err, &perr)).To(BeTrue())
+		Expect(perr.Kind).To(Equal(pubsub.EventTopicQueued))
+	})
+})
@@ -0,0 +1,66 @@
+package pubsub
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Count of last measured ping round-trip times kept by connection.
+const PingHistorySize = 20
+
+// DefaultPingPolicy is ping every 15 seconds with jitter and wait 10 seconds
+// for PONG response. Docs require ping at least once every 5 minutes and
+// recommend jitter to avoid all clients pinging at the same time.
+//
+// https://dev.twitch.tv/docs/pubsub/#connection-management
+var DefaultPingPolicy = PingPolicy{
+	Interval: TwitchApiPingEach,
+	Timeout:  TwitchApiPingTimeout,
+	Poll:     1 * time.Second,
+	Jitter:   0.1,
+}
+
+// PingPolicy is represent of ping/pong settings.
+type PingPolicy struct {
+	// Time between PONG response and next PING request,
+	// zero means default interval
+	Interval time.Duration
+
+	// Connection will be dropped if no PONG response during this time,
+	// zero means default timeout
+	Timeout time.Duration
+
+	// How often pinger will check interval and timeout
+	Poll time.Duration
+
+	// Random part of interval, from 0 to 1, 0.1 means +/- 10%
+	Jitter float64
+}
+
+// Next returns interval before next PING request with jitter.
+func (p PingPolicy) Next() time.Duration {
+	interval := float64(p.Interval)
+	if p.Interval <= 0 {
+		interval = float64(DefaultPingPolicy.Interval)
+	}
+	if p.Jitter > 0 {
+		interval += interval * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(interval)
+}
+
+// poll returns pinger poll interval, it can't be zero.
+func (p PingPolicy) poll() time.Duration {
+	if p.Poll <= 0 {
+		return DefaultPingPolicy.Poll
+	}
+	return p.Poll
+}
+
+// timeout returns PONG timeout, it can't be zero.
+func (p PingPolicy) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return DefaultPingPolicy.Timeout
+	}
+	return p.Timeout
+}
@@ -0,0 +1,125 @@
+package pubsub_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PingPolicy", func() {
+	Context("Next", func() {
+		It("add jitter to interval", func() {
+			p := pubsub.PingPolicy{Interval: 10 * time.Second, Jitter: 0.1}
+			for i := 0; i < 100; i++ {
+				Expect(p.Next()).To(BeNumerically(">=", 9*time.Second))
+				Expect(p.Next()).To(BeNumerically("<=", 11*time.Second))
+			}
+			Expect(pubsub.PingPolicy{Interval: time.Second}.Next()).To(Equal(time.Second))
+		})
+
+		It("use default interval if it's not set", func() {
+			Expect(pubsub.PingPolicy{}.Next()).To(Equal(pubsub.DefaultPingPolicy.Interval))
+		})
+	})
+
+	Context("Connection", func() {
+		var server *fakeServer
+
+		BeforeEach(func() {
+			server = newFakeServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("ping with configured interval and keep latency history", func() {
+			var pings, pongs int64
+
+			c := pubsub.NewConnection(server.URL(), pubsub.WithPingPolicy(pubsub.PingPolicy{
+				Interval: 20 * time.Millisecond,
+				Timeout:  time.Second,
+				Poll:     5 * time.Millisecond,
+			}))
+			defer c.Close()
+
+			c.OnPing(func(c *pubsub.Connection, start time.Time) {
+				atomic.AddInt64(&pings, 1)
+			})
+			c.OnPong(func(c *pubsub.Connection, start, end time.Time) {
+				atomic.AddInt64(&pongs, 1)
+			})
+
+			c.AddTopic("video-playback-by-id.1")
+
+			Eventually(func() int {
+				return len(c.Latency())
+			}, 5*time.Second).Should(Equal(pubsub.PingHistorySize))
+			Expect(atomic.LoadInt64(&pings)).To(BeNumerically(">=", pubsub.PingHistorySize))
+			Expect(atomic.LoadInt64(&pongs)).To(BeNumerically(">=", pubsub.PingHistorySize))
+
+			for _, rtt := range c.Latency() {
+				Expect(rtt).To(BeNumerically(">", 0))
+				Expect(rtt).To(BeNumerically("<", time.Second))
+			}
+		})
+
+		It("use default timeout if it's not set", func() {
+			var pongs, disconnects int64
+
+			c := pubsub.NewConnection(server.URL(), pubsub.WithPingPolicy(pubsub.PingPolicy{
+				Interval: 20 * time.Millisecond,
+				Poll:     5 * time.Millisecond,
+			}))
+			defer c.Close()
+
+			c.OnPong(func(c *pubsub.Connection, start, end time.Time) {
+				atomic.AddInt64(&pongs, 1)
+			})
+			c.OnDisconnect(func(c *pubsub.Connection) {
+				atomic.AddInt64(&disconnects, 1)
+			})
+
+			c.AddTopic("video-playback-by-id.1")
+
+			Eventually(func() int64 {
+				return atomic.LoadInt64(&pongs)
+			}, 5*time.Second).Should(BeNumerically(">=", 5))
+			Expect(atomic.LoadInt64(&disconnects)).To(BeZero())
+		})
+
+		It("reconnect on PONG timeout", func() {
+			server.SetMute(true)
+
+			var mu sync.Mutex
+			infos := []string{}
+
+			c := pubsub.NewConnection(server.URL(), pubsub.WithPingPolicy(pubsub.PingPolicy{
+				Interval: 20 * time.Millisecond,
+				Timeout:  50 * time.Millisecond,
+				Poll:     5 * time.Millisecond,
+			}))
+			defer c.Close()
+
+			c.OnInfo(func(c *pubsub.Connection, str string) {
+				mu.Lock()
+				defer mu.Unlock()
+				infos = append(infos, str)
+			})
+
+			c.AddTopic("video-playback-by-id.1")
+
+			Eventually(func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]string{}, infos...)
+			}, 5*time.Second).Should(ContainElement("warning, no PONG response more than 50ms"))
+			Expect(c.Latency()).To(BeEmpty())
+		})
+	})
+})
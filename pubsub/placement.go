@@ -0,0 +1,256 @@
+package pubsub
+
+import (
+	"sort"
+	"time"
+)
+
+// Placement is choose connection for new topic.
+type Placement interface {
+	// Place returns index of slot for topic or -1 if new connection
+	// is needed. Slot without free space means -1 too.
+	Place(topic Topic, token string, slots []*Slot) int
+}
+
+// Slot is represent of connection for placement, it includes topics
+// placed during current call.
+type Slot struct {
+	Connection *Connection
+	Topics     []string
+	Free       int
+
+	tokens map[string]struct{}
+}
+
+func newSlot(c *Connection) *Slot {
+	s := &Slot{
+		Connection: c,
+		Topics:     []string{},
+		tokens:     map[string]struct{}{},
+	}
+
+	c.RLock()
+	for topic, token := range c.topics {
+		s.Topics = append(s.Topics, topic)
+		s.tokens[token] = struct{}{}
+	}
+	c.RUnlock()
+
+	sort.Strings(s.Topics)
+	s.Free = TwitchApiMaxTopics - len(s.Topics)
+
+	return s
+}
+
+// HasAuthToken returns true if slot has topics with the same token.
+func (s *Slot) HasAuthToken(token string) bool {
+	_, ok := s.tokens[token]
+	return ok
+}
+
+func (s *Slot) add(topic, token string) {
+	s.Topics = append(s.Topics, topic)
+	s.tokens[token] = struct{}{}
+	s.Free--
+}
+
+// -----------------------------------------------------------------------------
+
+// FillFirst is fill connections in order of creation, connections with
+// the same token are preferred. It's default placement.
+type FillFirst struct{}
+
+func (FillFirst) Place(topic Topic, token string, slots []*Slot) int {
+	for i, s := range slots {
+		if s.Free > 0 && s.HasAuthToken(token) {
+			return i
+		}
+	}
+	for i, s := range slots {
+		if s.Free > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// SpreadEvenly is place topic to least filled connection,
+// so one reconnect will affect less topics.
+type SpreadEvenly struct{}
+
+func (SpreadEvenly) Place(topic Topic, token string, slots []*Slot) int {
+	best := -1
+	for i, s := range slots {
+		if s.Free > 0 && (best < 0 || s.Free > slots[best].Free) {
+			best = i
+		}
+	}
+	return best
+}
+
+// GroupByChannel is place all topics of one channel to the same connection,
+// so they will be listened and fail together. Channel is last topic ID.
+// New channel is placed to empty connection if there is one, new
+// connection is not asked while others have free space. Other topics
+// are placed by FillFirst.
+type GroupByChannel struct{}
+
+func (GroupByChannel) Place(topic Topic, token string, slots []*Slot) int {
+	channel := channelOf(topic)
+	if channel != "" {
+		for i, s := range slots {
+			if s.Free <= 0 {
+				continue
+			}
+			for _, t := range s.Topics {
+				if channelOf(Topic(t)) == channel {
+					return i
+				}
+			}
+		}
+
+		// New channel takes empty connection to keep space for next
+		// topics of channel, otherwise it's placed by FillFirst
+		for i, s := range slots {
+			if s.Free > 0 && len(s.Topics) <= 0 {
+				return i
+			}
+		}
+	}
+
+	return FillFirst{}.Place(topic, token, slots)
+}
+
+// channelOf returns channel ID of topic.
+func channelOf(topic Topic) string {
+	ids := topic.IDs()
+	if len(ids) <= 0 {
+		return ""
+	}
+	return ids[len(ids)-1]
+}
+
+// -----------------------------------------------------------------------------
+
+// queued is topic waiting for placement to connection.
+type queued struct {
+	topic  string
+	token  string
+	result *Result
+}
+
+// assign is place topics to connections by placement and limits, one LISTEN
+// request will be sent for every connection and token. Returns topics which
+// can't be placed yet, must be called under lock.
+func (p *PubSub) assign(items []*queued) []*queued {
+	type key struct {
+		c     *Connection
+		token string
+	}
+
+	slots := p.slots(p.sortedConnections())
+	plan := map[key][]*queued{}
+	order := []key{}
+	budget := p.limits.available(time.Now())
+
+	rest := []*queued{}
+	for _, item := range items {
+		// Keep order, next topics will wait too
+		if len(rest) > 0 {
+			rest = append(rest, item)
+			continue
+		}
+
+		var slot *Slot
+		if i := p.opts.placement.Place(Topic(item.topic), item.token, slots); i >= 0 && i < len(slots) && slots[i].Free > 0 {
+			slot = slots[i]
+		}
+
+		// Create new one
+		if slot == nil && budget > 0 && p.limits.connect(len(slots)) {
+			c := p.newConnection()
+			p.connections[c.id] = c
+			slot = newSlot(c)
+			slots = append(slots, slot)
+		}
+
+		// Every new group is one more LISTEN request
+		if slot != nil {
+			if _, ok := plan[key{slot.Connection, item.token}]; !ok {
+				if budget <= 0 {
+					slot = nil
+				} else {
+					budget--
+					order = append(order, key{slot.Connection, item.token})
+				}
+			}
+		}
+
+		if slot == nil {
+			rest = append(rest, item)
+			continue
+		}
+
+		slot.add(item.topic, item.token)
+		k := key{slot.Connection, item.token}
+		plan[k] = append(plan[k], item)
+	}
+
+	// LISTEN requests are counted by connections when they are sent
+	for _, k := range order {
+		topics := make([]string, 0, len(plan[k]))
+		for _, item := range plan[k] {
+			topics = append(topics, item.topic)
+		}
+		for i, result := range k.c.AddTopicsWithAuth(topics, k.token) {
+			forward(result, plan[k][i].result)
+		}
+	}
+
+	return rest
+}
+
+// slots returns slots of connections, must be called under lock.
+func (p *PubSub) slots(connections []*Connection) []*Slot {
+	slots := make([]*Slot, 0, len(connections))
+	for _, c := range connections {
+		// Topics can't be listened on connection which gave up
+		if !c.gaveUp() {
+			slots = append(slots, newSlot(c))
+		}
+	}
+	return slots
+}
+
+// placeQueued is try to place queued topics.
+func (p *PubSub) placeQueued() {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.closed || len(p.queue) <= 0 {
+		return
+	}
+
+	p.queue = p.assign(p.queue)
+}
+
+// queued returns queued topic, must be called under lock.
+func (p *PubSub) queued(topic string) *queued {
+	for _, item := range p.queue {
+		if item.topic == topic {
+			return item
+		}
+	}
+	return nil
+}
+
+// unqueue is remove topic from queue, must be called under lock.
+func (p *PubSub) unqueue(topic string) {
+	for i, item := range p.queue {
+		if item.topic == topic {
+			item.result.resolve(ErrUnlistened)
+			p.queue = append(p.queue[:i:i], p.queue[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package pubsub_test
+
+import (
+	"context"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Placement", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	slots := func() []*pubsub.Slot {
+		return []*pubsub.Slot{
+			{Topics: []string{"polls.1"}, Free: 0},
+			{Topics: []string{"polls.2", "polls.3"}, Free: 10},
+			{Topics: []string{"polls.4"}, Free: 20},
+		}
+	}
+
+	It("fill first connection", func() {
+		Expect(pubsub.FillFirst{}.Place(pubsub.Polls("5"), "", slots())).To(Equal(1))
+	})
+
+	It("spread topics evenly", func() {
+		Expect(pubsub.SpreadEvenly{}.Place(pubsub.Polls("5"), "", slots())).To(Equal(2))
+	})
+
+	It("group topics by channel", func() {
+		Expect(pubsub.GroupByChannel{}.Place(pubsub.HypeTrain("4"), "", slots())).To(Equal(2))
+		Expect(pubsub.GroupByChannel{}.Place(pubsub.AutoModQueue("9", "3"), "", slots())).To(Equal(1))
+		Expect(pubsub.GroupByChannel{}.Place(pubsub.HypeTrain("1"), "", slots())).To(Equal(1))
+	})
+
+	It("place new channel to connection with free space", func() {
+		one := []*pubsub.Slot{{Topics: []string{"polls.1"}, Free: 49}}
+		Expect(pubsub.GroupByChannel{}.Place(pubsub.Polls("2"), "", one)).To(Equal(0))
+	})
+
+	It("ask for new connection", func() {
+		full := []*pubsub.Slot{{Topics: []string{"polls.1"}, Free: 0}}
+		Expect(pubsub.FillFirst{}.Place(pubsub.Polls("5"), "", full)).To(Equal(-1))
+		Expect(pubsub.SpreadEvenly{}.Place(pubsub.Polls("5"), "", full)).To(Equal(-1))
+		Expect(pubsub.GroupByChannel{}.Place(pubsub.Polls("5"), "", full)).To(Equal(-1))
+	})
+
+	It("be used by PubSub", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithPlacement(pubsub.GroupByChannel{}))
+		defer ps.Close()
+
+		for _, result := range ps.ListenMany(ctx, videoTopics(1, 50)...) {
+			Expect(result.Wait(ctx)).To(Succeed())
+		}
+		Expect(ps.Listen(ctx, pubsub.Polls("100")).Wait(ctx)).To(Succeed())
+		Expect(ps.Unlisten(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+
+		// First connection has free space, but channel is on second one
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("100")).Wait(ctx)).To(Succeed())
+		Expect(ps.Connections()).To(HaveLen(2))
+		for _, c := range ps.Connections() {
+			_, polls := c.Topics["polls.100"]
+			_, video := c.Topics["video-playback-by-id.100"]
+			Expect(polls).To(Equal(video))
+		}
+	})
+
+	It("not create connection for new channel when there is free space", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithPlacement(pubsub.GroupByChannel{}))
+		defer ps.Close()
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("2")).Wait(ctx)).To(Succeed())
+		Expect(ps.Connections()).To(HaveLen(1))
+	})
+})
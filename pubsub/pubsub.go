@@ -7,8 +7,10 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,152 +23,457 @@ const TwitchApiPath = ""
 
 const TwitchApiMaxTopics = 50
 
+// Default time of graceful shutdown in Run.
+const DefaultShutdownTimeout = 10 * time.Second
+
 // PubSub is represent of API client.
 type PubSub struct {
 	sync.RWMutex
 
-	URL         url.URL
-	Connections map[int64]*Connection
+	URL url.URL
+
+	// Connections and per-client counter of their IDs
+	connections map[int64]*Connection
+	nextID      atomic.Int64
+
+	opts   options
+	closed bool
+	done   chan struct{}
+
+	// Client goroutines, like compactor and placer
+	wg sync.WaitGroup
+
+	// Only one compaction at the same time
+	compacting sync.Mutex
+
+	// Topics which can't be placed because of limits
+	queue  []*queued
+	limits *limiter
 
 	// Events
-	eventOnConnect    func(*Connection)
-	eventOnDisconnect func(*Connection)
-	eventOnError      func(*Connection, error)
-	eventOnInfo       func(*Connection, string)
-	eventOnMessage    func(*Connection, *Answer)
-	eventOnPing       func(*Connection, time.Time)
-	eventOnPong       func(*Connection, time.Time, time.Time)
+	eventOnConnect       handlers[func(*Connection)]
+	eventOnDisconnect    handlers[func(*Connection)]
+	eventOnError         handlers[func(*Connection, error)]
+	eventOnInfo          handlers[func(*Connection, string)]
+	eventOnMessage       handlers[func(*Connection, *Answer)]
+	eventOnPing          handlers[func(*Connection, time.Time)]
+	eventOnPong          handlers[func(*Connection, time.Time, time.Time)]
+	eventOnTopicRejected handlers[func(*Connection, string, string)]
+	eventOnReconnecting  handlers[func(*Connection, int, time.Duration)]
+	eventOnTopicQueued   handlers[func(string)]
+
+	// Events streams
+	subscribers map[*subscriber]struct{}
+
+	// Messages router
+	router Router
 }
 
 // New create and returns new API client.
-func New() *PubSub {
+// Options will be applied to every connection.
+func New(opts ...Option) *PubSub {
 	return NewWithURL(url.URL{
 		Scheme: TwitchApiScheme,
 		Host:   TwitchApiHost,
 		Path:   TwitchApiPath,
-	})
+	}, opts...)
 }
 
 // NewWithURL create and returns new API client with custom API server URL.
 // It can be useful for testing.
-func NewWithURL(url url.URL) *PubSub {
+func NewWithURL(url url.URL, opts ...Option) *PubSub {
 	p := PubSub{
 		URL:         url,
-		Connections: map[int64]*Connection{},
+		connections: map[int64]*Connection{},
+
+		opts: newOptions(opts...),
+		done: make(chan struct{}),
+
+		subscribers: map[*subscriber]struct{}{},
+	}
+
+	p.limits = newLimiter(p.opts.limit)
+
+	if p.opts.compact > 0 {
+		go_compactor(&p, p.opts.compact)
 	}
+
+	if p.opts.limit.limited() {
+		go_placer(&p)
+	}
+
 	return &p
 }
 
 // -----------------------------------------------------------------------------
 
 func (p *PubSub) newConnection() *Connection {
-	c := NewConnection(p.URL)
-	c.OnConnect(p.eventOnConnect)
-	c.OnDisconnect(p.eventOnDisconnect)
-	c.OnError(p.eventOnError)
-	c.OnInfo(p.eventOnInfo)
-	c.OnMessage(p.eventOnMessage)
-	c.OnPing(p.eventOnPing)
-	c.OnPong(p.eventOnPong)
+	c := newConnectionWithID(p.URL, p.nextID.Add(1)-1, p.limits, withOptions(p.opts))
+	c.OnConnect(func(c *Connection) {
+		p.emit(ConnectEvent{Connection: c})
+	})
+	c.OnDisconnect(func(c *Connection) {
+		p.emit(DisconnectEvent{Connection: c})
+	})
+	c.OnError(func(c *Connection, err error) {
+		p.emit(ErrorEvent{Connection: c, Err: err})
+	})
+	c.OnInfo(func(c *Connection, str string) {
+		p.emit(InfoEvent{Connection: c, Info: str})
+	})
+	c.OnMessage(func(c *Connection, msg *Answer) {
+		p.emit(MessageEvent{Connection: c, Message: msg})
+	})
+	c.OnPing(func(c *Connection, start time.Time) {
+		p.emit(PingEvent{Connection: c, Start: start})
+	})
+	c.OnPong(func(c *Connection, start, end time.Time) {
+		p.emit(PongEvent{Connection: c, Start: start, End: end})
+	})
+	c.OnTopicRejected(func(c *Connection, topic, reason string) {
+		p.emit(TopicRejectedEvent{Connection: c, Topic: topic, Reason: reason})
+	})
+	c.OnReconnecting(func(c *Connection, attempt int, delay time.Duration) {
+		p.emit(ReconnectingEvent{Connection: c, Attempt: attempt, Delay: delay})
+	})
 	return c
 }
 
+// protect is call func bound to client and emit ErrorEvent with PanicError
+// of connection c, c is nil for events without connection like TopicQueued.
+// Like in connection, panics of OnError funcs are not emitted again.
+func (p *PubSub) protect(c *Connection, kind EventKind, fn func()) {
+	if err := catch(kind, fn); err != nil && kind != EventError {
+		p.emit(ErrorEvent{Connection: c, Err: err})
+	}
+}
+
+// emit is fire event to bound funcs and to all events streams.
+// Bound funcs are read on every event, so they can be changed any time.
+func (p *PubSub) emit(e Event) {
+	switch e := e.(type) {
+	case ConnectEvent:
+		for _, fn := range p.eventOnConnect.all() {
+			p.protect(e.Connection, EventConnect, func() { fn(e.Connection) })
+		}
+	case DisconnectEvent:
+		for _, fn := range p.eventOnDisconnect.all() {
+			p.protect(e.Connection, EventDisconnect, func() { fn(e.Connection) })
+		}
+	case ErrorEvent:
+		for _, fn := range p.eventOnError.all() {
+			p.protect(e.Connection, EventError, func() { fn(e.Connection, e.Err) })
+		}
+	case InfoEvent:
+		for _, fn := range p.eventOnInfo.all() {
+			p.protect(e.Connection, EventInfo, func() { fn(e.Connection, e.Info) })
+		}
+	case MessageEvent:
+		for _, fn := range p.eventOnMessage.all() {
+			p.protect(e.Connection, EventMessage, func() { fn(e.Connection, e.Message) })
+		}
+		// Every route is protected alone, so panic not skip next routes
+		for _, fn := range p.router.matched(e.Message.GetData().Topic) {
+			p.protect(e.Connection, EventMessage, func() { fn(e.Connection, e.Message) })
+		}
+	case PingEvent:
+		for _, fn := range p.eventOnPing.all() {
+			p.protect(e.Connection, EventPing, func() { fn(e.Connection, e.Start) })
+		}
+	case PongEvent:
+		for _, fn := range p.eventOnPong.all() {
+			p.protect(e.Connection, EventPong, func() { fn(e.Connection, e.Start, e.End) })
+		}
+	case TopicRejectedEvent:
+		for _, fn := range p.eventOnTopicRejected.all() {
+			p.protect(e.Connection, EventTopicRejected, func() { fn(e.Connection, e.Topic, e.Reason) })
+		}
+	case ReconnectingEvent:
+		for _, fn := range p.eventOnReconnecting.all() {
+			p.protect(e.Connection, EventReconnecting, func() { fn(e.Connection, e.Attempt, e.Delay) })
+		}
+	case TopicQueuedEvent:
+		for _, fn := range p.eventOnTopicQueued.all() {
+			p.protect(nil, EventTopicQueued, func() { fn(e.Topic) })
+		}
+	}
+
+	p.RLock()
+	subscribers := make([]*subscriber, 0, len(p.subscribers))
+	for s := range p.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	p.RUnlock()
+
+	for _, s := range subscribers {
+		s.send(e)
+	}
+}
+
+// Events returns stream of events from all connections. Every call
+// returns new independent stream, it will be closed when context is done.
+func (p *PubSub) Events(ctx context.Context, opts ...EventsOption) <-chan Event {
+	s := newSubscriber(ctx, opts...)
+
+	p.Lock()
+	p.subscribers[s] = struct{}{}
+	p.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		p.Lock()
+		delete(p.subscribers, s)
+		p.Unlock()
+
+		s.close()
+	}()
+
+	return s.ch
+}
+
 // -----------------------------------------------------------------------------
 
 // Listen is adding topics for listening. It take care of API limits.
 // New TCP connection will be created for every 50 topics.
+// Returns result which will be resolved when API respond to LISTEN request.
+// Result error can be ErrAlreadyListening, ErrClosed, ErrInvalidTopic,
+// context error or API error, see ResponseError.
+//
+// If topic can't be placed because of LimitPolicy, it will be queued and
+// listened later, see OnTopicQueued.
+//
+// Topics of connection which gave up reconnecting are kept as TopicFailed,
+// listen of them again will place them to another connection.
 //
 // https://dev.twitch.tv/docs/pubsub/#connection-management
-func (p *PubSub) Listen(ctx context.Context, topic string, params ...interface{}) {
+func (p *PubSub) Listen(ctx context.Context, topic Topic, params ...interface{}) *Result {
+	return p.ListenWithAuth(ctx, "", topic, params...)
+}
+
+// ListenWithAuth is adding topics for listening with OAuth token.
+// Token is required by private topics like whispers, channel points,
+// bits or moderator actions. Topics with the same token will be grouped
+// to the same connections where it's possible, because one LISTEN request
+// can carry only one token.
+//
+// https://dev.twitch.tv/docs/pubsub/#topics
+func (p *PubSub) ListenWithAuth(ctx context.Context, token string, topic Topic, params ...interface{}) *Result {
+	return p.ListenManyWithAuth(ctx, token, Topic(p.Topic(string(topic), params...)))[0]
+}
+
+// ListenMany is adding many topics for listening at once. Topics are
+// distributed across connections in one pass and every connection sends
+// one LISTEN request only with new topics. Returns results in the same order.
+func (p *PubSub) ListenMany(ctx context.Context, topics ...Topic) []*Result {
+	return p.ListenManyWithAuth(ctx, "", topics...)
+}
+
+// ListenManyWithAuth is the same as ListenMany but with OAuth token.
+func (p *PubSub) ListenManyWithAuth(ctx context.Context, token string, topics ...Topic) []*Result {
+	results := make([]*Result, len(topics))
+
 	p.Lock()
-	defer p.Unlock()
 
-	// Create and add first connection
-	if len(p.Connections) <= 0 {
-		c := p.newConnection()
-		p.Connections[c.ID] = c
+	if err := p.check(ctx); err != nil {
+		p.Unlock()
+		for i := range results {
+			results[i] = resolvedResult(err)
+		}
+		return results
 	}
 
-	t := p.Topic(topic, params...)
+	connections := p.sortedConnections()
 
-	// Check topic in connection
-	// Don't continue if already present
-	for _, c := range p.Connections {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
+	items := []*queued{}
+	seen := map[string]struct{}{}
+	for i, topic := range topics {
+		t := topic.String()
 
-		if c.HasTopic(t) {
-			return
+		// Don't send malformed topic to API
+		if err := topic.Validate(); err != nil {
+			results[i] = resolvedResult(err)
+			continue
 		}
-	}
 
-	// Add topic to first not busy connection
-	for _, c := range p.Connections {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+		if _, ok := seen[t]; ok {
+			results[i] = resolvedResult(ErrAlreadyListening)
+			continue
+		}
+		seen[t] = struct{}{}
+
+		// Already present, only update token
+		// Topic of connection which gave up will be placed again
+		if c := p.owner(t); c != nil {
+			if !c.gaveUp() {
+				results[i] = c.AddTopicWithAuth(t, token)
+				continue
+			}
+			c.RemoveTopics([]string{t})
+		}
+		if item := p.queued(t); item != nil {
+			if item.token == token {
+				results[i] = resolvedResult(ErrAlreadyListening)
+			} else {
+				item.token = token
+				results[i] = resolvedResult(nil)
+			}
+			continue
 		}
 
-		if c.TopicsCount() < TwitchApiMaxTopics {
-			c.AddTopic(t)
-			return
+		// Listen again is a new chance for rejected topic
+		for _, c := range connections {
+			c.forgetRejected(t)
 		}
+
+		results[i] = newResult()
+		items = append(items, &queued{topic: t, token: token, result: results[i]})
 	}
 
-	// Create new one and add
-	c := p.newConnection()
-	p.Connections[c.ID] = c
-	c.AddTopic(t)
+	// Keep order, new topics will wait for already queued
+	rest := items
+	if len(p.queue) <= 0 {
+		rest = p.assign(items)
+	}
+	p.queue = append(p.queue, rest...)
+
+	p.closeEmpty()
+
+	p.Unlock()
+
+	for _, item := range rest {
+		p.emit(TopicQueuedEvent{Topic: item.topic})
+	}
+
+	return results
 }
 
 // Unlisten is remove topics from listening. It take care of API limits too.
 // Connection count will automatically decrease of needs.
+// Returns result which will be resolved when API respond to UNLISTEN request.
+// Result error can be ErrClosed, WriteError or context error.
 //
 // https://dev.twitch.tv/docs/pubsub/#connection-management
-func (p *PubSub) Unlisten(ctx context.Context, topic string, params ...interface{}) {
+func (p *PubSub) Unlisten(ctx context.Context, topic Topic, params ...interface{}) *Result {
+	return p.UnlistenMany(ctx, Topic(p.Topic(string(topic), params...)))[0]
+}
+
+// UnlistenMany is remove many topics from listening at once. Every
+// connection sends one UNLISTEN request. Returns results in the same order.
+func (p *PubSub) UnlistenMany(ctx context.Context, topics ...Topic) []*Result {
+	results := make([]*Result, len(topics))
+
 	p.Lock()
 	defer p.Unlock()
 
-	t := p.Topic(topic, params...)
-
-	// Search and unlisten
-	for _, c := range p.Connections {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+	if err := p.check(ctx); err != nil {
+		for i := range results {
+			results[i] = resolvedResult(err)
 		}
+		return results
+	}
 
-		if c.HasTopic(t) {
-			c.RemoveTopic(t)
+	connections := p.sortedConnections()
 
-			// Must not contain duplicates
-			// So just remove first and break
-			break
+	// Topics of connections and indexes of their results
+	plan := map[*Connection][]string{}
+	index := map[*Connection][]int{}
+
+	for i, topic := range topics {
+		t := topic.String()
+		results[i] = resolvedResult(nil)
+
+		// Not placed yet
+		p.unqueue(t)
+
+		for _, c := range connections {
+			if c.HasTopic(t) {
+				plan[c] = append(plan[c], t)
+				index[c] = append(index[c], i)
+				break
+			}
 		}
 	}
 
-	// Remove empty connections
-	for i, c := range p.Connections {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+	for _, c := range connections {
+		if len(plan[c]) <= 0 {
+			continue
 		}
+		for j, result := range c.RemoveTopics(plan[c]) {
+			results[index[c][j]] = result
+		}
+	}
+
+	p.closeEmpty()
+
+	return results
+}
+
+// check returns error if topics can't be changed, must be called under lock.
+func (p *PubSub) check(ctx context.Context) error {
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
 
+	return nil
+}
+
+// sortedConnections returns connections in order of creation,
+// must be called under lock.
+func (p *PubSub) sortedConnections() []*Connection {
+	connections := make([]*Connection, 0, len(p.connections))
+	for _, c := range p.connections {
+		connections = append(connections, c)
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].id < connections[j].id
+	})
+	return connections
+}
+
+// closeEmpty is close and remove connections without topics,
+// must be called under lock.
+func (p *PubSub) closeEmpty() {
+	for i, c := range p.connections {
 		if c.TopicsCount() <= 0 {
 			_ = c.Close()
-			delete(p.Connections, i)
+			delete(p.connections, i)
+		}
+	}
+}
 
-			// Can't be more than one connection without topics
-			// So just close, remove first and break
-			break
+// owner returns connection which has topic, must be called under lock.
+func (p *PubSub) owner(topic string) *Connection {
+	for _, c := range p.connections {
+		if c.HasTopic(topic) {
+			return c
 		}
 	}
+	return nil
+}
+
+// Connections returns snapshot of current connections in order of creation,
+// see ConnectionStatus. Connections itself are managed only by client.
+func (p *PubSub) Connections() []ConnectionStatus {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.statuses()
+}
+
+// statuses returns snapshot of connections, must be called under lock.
+func (p *PubSub) statuses() []ConnectionStatus {
+	statuses := make([]ConnectionStatus, 0, len(p.connections))
+	for _, c := range p.sortedConnections() {
+		statuses = append(statuses, c.Status())
+	}
+	return statuses
 }
 
 // Topics returns all current listen topics.
@@ -175,23 +482,21 @@ func (p *PubSub) Topics() []string {
 	defer p.Unlock()
 
 	topics := []string{}
-	for _, c := range p.Connections {
-		for topic := range c.topics {
-			topics = append(topics, topic)
-		}
+	for _, c := range p.connections {
+		topics = append(topics, c.Topics()...)
 	}
 
 	return topics
 }
 
 // HasTopic returns true if topic present.
-func (p *PubSub) HasTopic(topic string, params ...interface{}) bool {
+func (p *PubSub) HasTopic(topic Topic, params ...interface{}) bool {
 	p.Lock()
 	defer p.Unlock()
 
-	t := p.Topic(topic, params...)
+	t := p.Topic(string(topic), params...)
 
-	for _, c := range p.Connections {
+	for _, c := range p.connections {
 		if c.HasTopic(t) {
 			return true
 		}
@@ -200,6 +505,58 @@ func (p *PubSub) HasTopic(topic string, params ...interface{}) bool {
 	return false
 }
 
+// RejectedTopics returns topics which was rejected by API with reasons.
+// Such topics will not be listened again until next Listen call.
+func (p *PubSub) RejectedTopics() map[string]string {
+	p.Lock()
+	defer p.Unlock()
+
+	rejected := map[string]string{}
+	for _, c := range p.connections {
+		for topic, reason := range c.RejectedTopics() {
+			rejected[topic] = reason
+		}
+	}
+
+	return rejected
+}
+
+// TopicStates returns states of all topics, pending topics are not
+// acknowledged by API yet.
+func (p *PubSub) TopicStates() map[string]TopicState {
+	p.Lock()
+	defer p.Unlock()
+
+	states := map[string]TopicState{}
+	for _, c := range p.connections {
+		for topic, state := range c.TopicStates() {
+			states[topic] = state
+		}
+	}
+	for _, item := range p.queue {
+		states[item.topic] = TopicQueued
+	}
+
+	return states
+}
+
+// Status returns snapshot of all connections and queued topics.
+// It's safe to use it from any goroutine, for example for health checks.
+func (p *PubSub) Status() Status {
+	p.RLock()
+	defer p.RUnlock()
+
+	status := Status{
+		Connections: p.statuses(),
+		Queued:      make([]string, 0, len(p.queue)),
+	}
+	for _, item := range p.queue {
+		status.Queued = append(status.Queued, item.topic)
+	}
+
+	return status
+}
+
 // TopicsCount return count of topics.
 func (p *PubSub) TopicsCount() int {
 	p.Lock()
@@ -207,7 +564,7 @@ func (p *PubSub) TopicsCount() int {
 
 	count := 0
 
-	for _, c := range p.Connections {
+	for _, c := range p.connections {
 		count += c.TopicsCount()
 	}
 
@@ -216,6 +573,7 @@ func (p *PubSub) TopicsCount() int {
 
 // Topic generate correct topic for API.
 // Params can be as number or string.
+// Prefer typed constructors like ChannelPoints or Whispers.
 //
 // https://dev.twitch.tv/docs/pubsub/#topics
 func (p *PubSub) Topic(topic string, params ...interface{}) string {
@@ -231,58 +589,216 @@ func (p *PubSub) Topic(topic string, params ...interface{}) string {
 	return fmt.Sprintf("%s.%s", topic, strings.Join(list, "."))
 }
 
+// SetReconnectPolicy is change delays between reconnect attempts
+// for all current and new connections.
+//
+// https://dev.twitch.tv/docs/pubsub/#connection-management
+func (p *PubSub) SetReconnectPolicy(policy ReconnectPolicy) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.opts.reconnect = policy
+	for _, c := range p.connections {
+		c.SetReconnectPolicy(policy)
+	}
+}
+
+// SetPingPolicy is change ping interval and PONG timeout
+// for all current and new connections.
+func (p *PubSub) SetPingPolicy(policy PingPolicy) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.opts.ping = policy
+	for _, c := range p.connections {
+		c.SetPingPolicy(policy)
+	}
+}
+
+// Dropped returns count of messages dropped by dispatch overflow policy
+// of all current connections.
+func (p *PubSub) Dropped() uint64 {
+	p.RLock()
+	defer p.RUnlock()
+
+	var dropped uint64
+	for _, c := range p.connections {
+		dropped += c.Dropped()
+	}
+	return dropped
+}
+
+// Use adds middlewares around messages handlers for all connections.
+func (p *PubSub) Use(middlewares ...Middleware) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.opts.middlewares = append(p.opts.middlewares, middlewares...)
+	for _, c := range p.connections {
+		c.Use(middlewares...)
+	}
+}
+
 // Close is close all connections.
-// Usually need to call at the end of app life.
+// Usually need to call at the end of app life, topics can't be listened
+// after it.
 func (p *PubSub) Close() {
 	p.Lock()
 	defer p.Unlock()
 
-	for i, c := range p.Connections {
+	p.close()
+
+	for i, c := range p.connections {
 		_ = c.Close()
-		delete(p.Connections, i)
+		delete(p.connections, i)
+	}
+}
+
+// close is mark client as closed and stop its goroutines,
+// must be called under lock.
+func (p *PubSub) close() {
+	if !p.closed {
+		p.closed = true
+		close(p.done)
+	}
+
+	for _, item := range p.queue {
+		item.result.resolve(ErrClosed)
+	}
+	p.queue = nil
+}
+
+// Shutdown is gracefully close all connections, see Connection.Shutdown.
+// Returns context error if context is done earlier.
+// It's safe to call it many times.
+func (p *PubSub) Shutdown(ctx context.Context) error {
+	p.Lock()
+	p.close()
+	p.Unlock()
+
+	// Client goroutines use connections, so stop them first
+	err := wait(ctx, &p.wg)
+
+	p.Lock()
+	connections := make([]*Connection, 0, len(p.connections))
+	for i, c := range p.connections {
+		connections = append(connections, c)
+		delete(p.connections, i)
+	}
+	p.Unlock()
+
+	// Connections goroutines fire events, so wait without lock
+	errs := make(chan error, len(connections))
+	for _, c := range connections {
+		go func(c *Connection) {
+			errs <- c.Shutdown(ctx)
+		}(c)
+	}
+
+	for range connections {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
 	}
+
+	return err
+}
+
+// Run is blocking until context is done, then gracefully close all
+// connections during DefaultShutdownTimeout.
+func (p *PubSub) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+
+	return p.Shutdown(ctx)
 }
 
 // -----------------------------------------------------------------------------
 
-// OnConnect is bind func to event.
-// Will fire for every connection.
-func (c *PubSub) OnConnect(fn func(*Connection)) {
-	c.eventOnConnect = fn
+// Handle is bind handler to messages of matched topics, for example
+// whispers.123, channel-points-channel-v1.* or only family whispers.
+// Returns func which unbind it.
+func (p *PubSub) Handle(pattern string, fn MessageHandler) func() {
+	return p.router.Handle(pattern, fn)
+}
+
+// HandleDefault is bind handler to messages which not matched by any Handle
+// pattern. Returns func which unbind it.
+func (p *PubSub) HandleDefault(fn MessageHandler) func() {
+	return p.router.HandleDefault(fn)
 }
 
-// OnDisconnect is bind func to event.
+// OnConnect is bind func to event, many funcs can be bound.
 // Will fire for every connection.
-func (c *PubSub) OnDisconnect(fn func(*Connection)) {
-	c.eventOnDisconnect = fn
+// Returns func which unbind it.
+func (p *PubSub) OnConnect(fn func(*Connection)) func() {
+	return p.eventOnConnect.add(fn)
 }
 
-// OnError is bind func to event.
+// OnDisconnect is bind func to event, many funcs can be bound.
 // Will fire for every connection.
-func (c *PubSub) OnError(fn func(*Connection, error)) {
-	c.eventOnError = fn
+// Returns func which unbind it.
+func (p *PubSub) OnDisconnect(fn func(*Connection)) func() {
+	return p.eventOnDisconnect.add(fn)
+}
+
+// OnError is bind func to event, many funcs can be bound.
+// Will fire for every connection. Connection is nil for errors
+// of client itself, for example panic in OnTopicQueued func.
+// Returns func which unbind it.
+func (p *PubSub) OnError(fn func(*Connection, error)) func() {
+	return p.eventOnError.add(fn)
 }
 
-// OnInfo is bind func to event.
+// OnInfo is bind func to event, many funcs can be bound.
 // Will fire for every connection.
-func (c *PubSub) OnInfo(fn func(*Connection, string)) {
-	c.eventOnInfo = fn
+// Returns func which unbind it.
+func (p *PubSub) OnInfo(fn func(*Connection, string)) func() {
+	return p.eventOnInfo.add(fn)
 }
 
-// OnMessage is bind func to event.
+// OnMessage is bind func to event, many funcs can be bound.
 // Will fire for every connection.
-func (c *PubSub) OnMessage(fn func(*Connection, *Answer)) {
-	c.eventOnMessage = fn
+// Returns func which unbind it.
+func (p *PubSub) OnMessage(fn func(*Connection, *Answer)) func() {
+	return p.eventOnMessage.add(fn)
 }
 
-// OnPing is bind func to event.
+// OnPing is bind func to event, many funcs can be bound.
 // Will fire for every connection.
-func (c *PubSub) OnPing(fn func(*Connection, time.Time)) {
-	c.eventOnPing = fn
+// Returns func which unbind it.
+func (p *PubSub) OnPing(fn func(*Connection, time.Time)) func() {
+	return p.eventOnPing.add(fn)
 }
 
-// OnPong is bind func to event.
+// OnPong is bind func to event, many funcs can be bound.
 // Will fire for every connection.
-func (c *PubSub) OnPong(fn func(*Connection, time.Time, time.Time)) {
-	c.eventOnPong = fn
+// Returns func which unbind it.
+func (p *PubSub) OnPong(fn func(*Connection, time.Time, time.Time)) func() {
+	return p.eventOnPong.add(fn)
+}
+
+// OnTopicRejected is bind func to event, many funcs can be bound.
+// Will fire for every topic rejected by API, with topic and reason.
+// Returns func which unbind it.
+func (p *PubSub) OnTopicRejected(fn func(*Connection, string, string)) func() {
+	return p.eventOnTopicRejected.add(fn)
+}
+
+// OnReconnecting is bind func to event, many funcs can be bound.
+// Will fire after failed reconnect attempt with next attempt number
+// and delay before it.
+// Returns func which unbind it.
+func (p *PubSub) OnReconnecting(fn func(*Connection, int, time.Duration)) func() {
+	return p.eventOnReconnecting.add(fn)
+}
+
+// OnTopicQueued is bind func to event, many funcs can be bound.
+// Will fire when topic can't be listened now because of LimitPolicy,
+// it will be listened later when connection will be available.
+// Returns func which unbind it.
+func (p *PubSub) OnTopicQueued(fn func(string)) func() {
+	return p.eventOnTopicQueued.add(fn)
 }
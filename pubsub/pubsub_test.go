@@ -2,9 +2,14 @@ package pubsub_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/vladimirok5959/golang-twitch/pubsub"
 
@@ -28,63 +33,63 @@ var _ = Describe("PubSub", func() {
 
 		Context("Listen", func() {
 			It("create new connection for each 50 topics", func() {
-				Expect(len(ps.Connections)).To(Equal(0))
+				Expect(len(ps.Connections())).To(Equal(0))
 
 				for i := 1; i <= 45; i++ {
 					ps.Listen(ctx, "community-points-channel-v1", 1, i)
 				}
-				Expect(len(ps.Connections)).To(Equal(1))
+				Expect(len(ps.Connections())).To(Equal(1))
 
 				for i := 1; i <= 5; i++ {
 					ps.Listen(ctx, "community-points-channel-v1", 1, i)
 				}
-				Expect(len(ps.Connections)).To(Equal(1))
+				Expect(len(ps.Connections())).To(Equal(1))
 
 				for i := 1; i <= 50; i++ {
 					ps.Listen(ctx, "community-points-channel-v1", 2, i)
 				}
-				Expect(len(ps.Connections)).To(Equal(2))
+				Expect(len(ps.Connections())).To(Equal(2))
 
 				for i := 1; i <= 50; i++ {
 					ps.Listen(ctx, "community-points-channel-v1", 3, i)
 				}
-				Expect(len(ps.Connections)).To(Equal(3))
+				Expect(len(ps.Connections())).To(Equal(3))
 			})
 		})
 
 		Context("Unlisten", func() {
 			It("remove connection without topics", func() {
-				Expect(len(ps.Connections)).To(Equal(0))
+				Expect(len(ps.Connections())).To(Equal(0))
 
 				for i := 1; i <= 50; i++ {
 					ps.Listen(ctx, "community-points-channel-v1", 1, i)
 				}
-				Expect(len(ps.Connections)).To(Equal(1))
+				Expect(len(ps.Connections())).To(Equal(1))
 
 				ps.Listen(ctx, "community-points-channel-v1", 2, 1)
-				Expect(len(ps.Connections)).To(Equal(2))
+				Expect(len(ps.Connections())).To(Equal(2))
 
 				ps.Unlisten(ctx, "community-points-channel-v1", 2, 1)
-				Expect(len(ps.Connections)).To(Equal(1))
+				Expect(len(ps.Connections())).To(Equal(1))
 
 				for i := 1; i <= 50; i++ {
 					ps.Unlisten(ctx, "community-points-channel-v1", 1, i)
 				}
-				Expect(len(ps.Connections)).To(Equal(0))
+				Expect(len(ps.Connections())).To(Equal(0))
 			})
 		})
 
 		Context("Topics", func() {
 			It("return topics", func() {
-				Expect(len(ps.Connections)).To(Equal(0))
+				Expect(len(ps.Connections())).To(Equal(0))
 
 				for i := 1; i <= 50; i++ {
 					ps.Listen(ctx, "community-points-channel-v1", 1, i)
 				}
-				Expect(len(ps.Connections)).To(Equal(1))
+				Expect(len(ps.Connections())).To(Equal(1))
 
 				ps.Listen(ctx, "community-points-channel-v1", 2, 1)
-				Expect(len(ps.Connections)).To(Equal(2))
+				Expect(len(ps.Connections())).To(Equal(2))
 
 				Expect(ps.Topics()).To(ContainElements(
 					"community-points-channel-v1.2.1",
@@ -94,7 +99,7 @@ var _ = Describe("PubSub", func() {
 
 		Context("HasTopic", func() {
 			It("checks topics", func() {
-				Expect(len(ps.Connections)).To(Equal(0))
+				Expect(len(ps.Connections())).To(Equal(0))
 
 				ps.Listen(ctx, "community-points-channel-v1", 1)
 				Expect(ps.HasTopic("unknown")).To(BeFalse())
@@ -188,6 +193,21 @@ var _ = Describe("PubSub", func() {
 
 				Expect(c.TopicsCount()).To(Equal(50))
 			})
+
+			It("return typed errors", func() {
+				Expect(c.AddTopic("community-points-channel-v1.1").Err()).To(Succeed())
+				Expect(c.AddTopic("community-points-channel-v1.1").Err()).To(MatchError(pubsub.ErrAlreadyListening))
+				Expect(c.AddTopicWithAuth("community-points-channel-v1.1", "token").Err()).To(Succeed())
+
+				for i := 2; i <= 50; i++ {
+					c.AddTopic(fmt.Sprintf("community-points-channel-v1.%d", i))
+				}
+				Expect(errors.Is(c.AddTopic("community-points-channel-v1.51").Err(), pubsub.ErrTopicLimit)).To(BeTrue())
+
+				c.Close()
+				Expect(c.AddTopic("community-points-channel-v1.52").Err()).To(MatchError(pubsub.ErrClosed))
+				Expect(c.RemoveTopic("community-points-channel-v1.1").Err()).To(MatchError(pubsub.ErrClosed))
+			})
 		})
 
 		Context("RemoveTopic", func() {
@@ -260,6 +280,338 @@ var _ = Describe("PubSub", func() {
 			})
 		})
 	})
+
+	Context("Server", func() {
+		var ctx context.Context
+		var server *fakeServer
+		var ps *pubsub.PubSub
+		withFakeServer(&ctx, &server)
+
+		// listened returns topics with auth tokens from all LISTEN requests
+		listened := func() map[string]string {
+			topics := map[string]string{}
+			for _, f := range server.Frames(pubsub.Listen) {
+				for _, topic := range f.Data.Topics {
+					topics[topic] = f.Data.AuthToken
+				}
+			}
+			return topics
+		}
+
+		BeforeEach(func() {
+			ps = pubsub.NewWithURL(server.URL())
+		})
+
+		AfterEach(func() {
+			ps.Close()
+		})
+
+		Context("ListenWithAuth", func() {
+			It("send auth token with LISTEN request", func() {
+				ps.ListenWithAuth(ctx, "token1", "whispers", 1)
+				ps.ListenWithAuth(ctx, "token2", "whispers", 2)
+				ps.Listen(ctx, "video-playback-by-id", 3)
+
+				Eventually(listened, 5*time.Second).Should(Equal(map[string]string{
+					"whispers.1":             "token1",
+					"whispers.2":             "token2",
+					"video-playback-by-id.3": "",
+				}))
+
+				for _, f := range server.Frames(pubsub.Listen) {
+					for _, topic := range f.Data.Topics {
+						Expect(listened()[topic]).To(Equal(f.Data.AuthToken))
+					}
+				}
+			})
+
+			It("send actual auth token after reconnect", func() {
+				ps.ListenWithAuth(ctx, "token1", "whispers", 1)
+				Eventually(listened, 5*time.Second).Should(HaveKeyWithValue("whispers.1", "token1"))
+
+				ps.ListenWithAuth(ctx, "token2", "whispers", 1)
+				Expect(ps.TopicsCount()).To(Equal(1))
+
+				count := len(server.Frames(pubsub.Listen))
+				server.DropConnections()
+				Eventually(func() int {
+					return len(server.Frames(pubsub.Listen))
+				}, 5*time.Second).Should(BeNumerically(">", count))
+				Expect(listened()).To(HaveKeyWithValue("whispers.1", "token2"))
+			})
+		})
+
+		Context("Result", func() {
+			It("resolve LISTEN and UNLISTEN by response", func() {
+				Expect(ps.Listen(ctx, "video-playback-by-id", 1).Wait(ctx)).To(Succeed())
+				Expect(ps.Listen(ctx, "video-playback-by-id", 2).Wait(ctx)).To(Succeed())
+				Expect(ps.Unlisten(ctx, "video-playback-by-id", 1).Wait(ctx)).To(Succeed())
+
+				for _, f := range server.Frames(pubsub.Listen) {
+					Expect(f.Nonce).NotTo(BeEmpty())
+				}
+				Expect(server.Frames(pubsub.Unlisten)).To(HaveLen(1))
+				Expect(server.Frames(pubsub.Unlisten)[0].Nonce).NotTo(BeEmpty())
+			})
+
+			It("return API error", func() {
+				server.SetReject(func(f frame) string {
+					return "ERR_BADAUTH"
+				})
+
+				err := ps.ListenWithAuth(ctx, "bad", "whispers", 1).Wait(ctx)
+				Expect(errors.Is(err, pubsub.ErrBadAuth)).To(BeTrue())
+				Expect(errors.Is(err, pubsub.ErrBadTopic)).To(BeFalse())
+
+				var rerr *pubsub.ResponseError
+				Expect(errors.As(err, &rerr)).To(BeTrue())
+				Expect(rerr.Type).To(Equal(pubsub.Listen))
+				Expect(rerr.Topics).To(Equal([]string{"whispers.1"}))
+				Expect(err).To(MatchError("pubsub: LISTEN whispers.1 failed: ERR_BADAUTH"))
+			})
+
+			It("listen topic by response after timeout", func() {
+				server.SetDelay(pubsub.TwitchApiResponseTimeout + 500*time.Millisecond)
+
+				ctx, cancel := context.WithTimeout(context.Background(), 2*pubsub.TwitchApiResponseTimeout)
+				defer cancel()
+
+				Expect(ps.Listen(ctx, "video-playback-by-id", 1).Wait(ctx)).To(MatchError(pubsub.ErrTimeout))
+				Expect(ps.TopicStates()).To(HaveKeyWithValue("video-playback-by-id.1", pubsub.TopicFailed))
+
+				Eventually(ps.TopicStates, 5*time.Second).Should(HaveKeyWithValue("video-playback-by-id.1", pubsub.TopicListening))
+			})
+
+			It("return context error", func() {
+				canceled, cancel := context.WithCancel(ctx)
+				cancel()
+
+				result := ps.Listen(canceled, "video-playback-by-id", 1)
+				Expect(result.Done()).To(BeClosed())
+				Expect(result.Err()).To(MatchError(context.Canceled))
+				Expect(ps.TopicsCount()).To(Equal(0))
+				Expect(server.Frames(pubsub.Listen)).To(BeEmpty())
+			})
+
+			It("return already listening and closed errors", func() {
+				Expect(ps.Listen(ctx, "video-playback-by-id", 1).Wait(ctx)).To(Succeed())
+				Expect(ps.Listen(ctx, "video-playback-by-id", 1).Wait(ctx)).To(MatchError(pubsub.ErrAlreadyListening))
+
+				ps.Close()
+				Expect(ps.Listen(ctx, "video-playback-by-id", 2).Wait(ctx)).To(MatchError(pubsub.ErrClosed))
+				Expect(ps.Unlisten(ctx, "video-playback-by-id", 1).Wait(ctx)).To(MatchError(pubsub.ErrClosed))
+			})
+
+			It("wrap write errors", func() {
+				var stuck atomic.Bool
+				c := pubsub.NewConnection(server.URL(), pubsub.WithDialer(stuckDialer(&stuck)))
+				defer c.Close()
+
+				var mu sync.Mutex
+				errs := []error{}
+				c.OnError(func(c *pubsub.Connection, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					errs = append(errs, err)
+				})
+
+				Expect(c.AddTopic("video-playback-by-id.1").Wait(ctx)).To(Succeed())
+
+				// Writer is blocked, so write queue will be full
+				stuck.Store(true)
+				for i := 0; i <= pubsub.TwitchApiWriteQueue; i++ {
+					c.AddTopic("video-playback-by-id.2")
+					c.RemoveTopic("video-playback-by-id.2")
+				}
+
+				var err error
+				Eventually(func() bool {
+					mu.Lock()
+					defer mu.Unlock()
+					for _, e := range errs {
+						if errors.Is(e, pubsub.ErrWriteQueue) {
+							err = e
+							return true
+						}
+					}
+					return false
+				}, 5*time.Second).Should(BeTrue())
+
+				var werr *pubsub.WriteError
+				Expect(errors.As(err, &werr)).To(BeTrue())
+				Expect(werr.Type).To(BeElementOf(pubsub.Listen, pubsub.Unlisten))
+				Expect(err.Error()).To(ContainSubstring(string(werr.Type)))
+			})
+		})
+
+		Context("OnTopicRejected", func() {
+			It("isolate and reject bad topic", func() {
+				server.SetReject(func(f frame) string {
+					for _, topic := range f.Data.Topics {
+						if topic == "video-playback-by-id.666" {
+							return "ERR_BADTOPIC"
+						}
+					}
+					return ""
+				})
+
+				var mu sync.Mutex
+				rejected := map[string]string{}
+				ps.OnTopicRejected(func(c *pubsub.Connection, topic, reason string) {
+					mu.Lock()
+					defer mu.Unlock()
+					rejected[topic] = reason
+				})
+
+				r1 := ps.Listen(ctx, "video-playback-by-id", 1)
+				r2 := ps.Listen(ctx, "video-playback-by-id", 666)
+				r3 := ps.Listen(ctx, "video-playback-by-id", 3)
+
+				Expect(r1.Wait(ctx)).To(Succeed())
+				Expect(errors.Is(r2.Wait(ctx), pubsub.ErrBadTopic)).To(BeTrue())
+				Expect(r3.Wait(ctx)).To(Succeed())
+
+				Eventually(func() map[string]string {
+					mu.Lock()
+					defer mu.Unlock()
+					return rejected
+				}).Should(Equal(map[string]string{"video-playback-by-id.666": "ERR_BADTOPIC"}))
+				Expect(ps.RejectedTopics()).To(Equal(map[string]string{"video-playback-by-id.666": "ERR_BADTOPIC"}))
+				Expect(ps.HasTopic("video-playback-by-id", 666)).To(BeFalse())
+				Expect(ps.TopicsCount()).To(Equal(2))
+
+				count := len(server.Frames(pubsub.Listen))
+				server.DropConnections()
+				Eventually(func() int {
+					return len(server.Frames(pubsub.Listen))
+				}, 5*time.Second).Should(BeNumerically(">", count))
+
+				frames := server.Frames(pubsub.Listen)
+				Expect(frames[len(frames)-1].Data.Topics).To(Equal([]string{
+					"video-playback-by-id.1",
+					"video-playback-by-id.3",
+				}))
+			})
+		})
+
+		Context("Concurrency", func() {
+			It("handle concurrent Listen, Unlisten, events and reconnects", func() {
+				var wg sync.WaitGroup
+				stop := make(chan struct{})
+
+				loop := func(fn func()) {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for {
+							select {
+							case <-stop:
+								return
+							case <-time.After(10 * time.Millisecond):
+								fn()
+							}
+						}
+					}()
+				}
+
+				loop(func() {
+					unbind := ps.OnMessage(func(c *pubsub.Connection, msg *pubsub.Answer) {})
+					ps.OnPong(func(c *pubsub.Connection, start, end time.Time) {})()
+					unbind()
+				})
+				loop(func() {
+					_ = ps.Topics()
+					_ = ps.TopicsCount()
+					_ = ps.HasTopic(pubsub.VideoPlayback("1001"))
+					_ = ps.RejectedTopics()
+				})
+				loop(func() {
+					server.Publish("video-playback-by-id.1001", `{"type":"viewcount","viewers":1}`)
+				})
+				loop(func() {
+					if rand.Intn(20) == 0 {
+						server.DropConnections()
+					}
+				})
+
+				var writers sync.WaitGroup
+				for w := 1; w <= 8; w++ {
+					writers.Add(1)
+					go func(w int) {
+						defer writers.Done()
+						for i := 1; i <= 200; i++ {
+							ps.Listen(ctx, pubsub.VideoPlayback(fmt.Sprint(w*1000+i%60)))
+							if i%3 == 0 {
+								ps.Unlisten(ctx, pubsub.VideoPlayback(fmt.Sprint(w*1000+rand.Intn(60))))
+							}
+						}
+					}(w)
+				}
+				writers.Wait()
+
+				time.Sleep(3 * time.Second)
+				close(stop)
+				wg.Wait()
+
+				Expect(ps.TopicsCount()).To(Equal(len(ps.Topics())))
+			})
+
+			It("handle concurrent topics changes and close of connection", func() {
+				c := pubsub.NewConnection(server.URL())
+
+				var wg sync.WaitGroup
+				for w := 1; w <= 8; w++ {
+					wg.Add(1)
+					go func(w int) {
+						defer wg.Done()
+						for i := 1; i <= 100; i++ {
+							topic := fmt.Sprintf("video-playback-by-id.%d", w*100+i%10)
+							c.AddTopic(topic)
+							_ = c.HasTopic(topic)
+							_ = c.TopicsCount()
+							_ = c.Topics()
+							if i%2 == 0 {
+								c.RemoveTopic(topic)
+							}
+							if i%25 == 0 {
+								time.Sleep(100 * time.Millisecond)
+							}
+						}
+					}(w)
+				}
+
+				time.Sleep(1500 * time.Millisecond)
+				Expect(c.Close()).To(Succeed())
+				wg.Wait()
+			})
+		})
+
+		Context("Writer", func() {
+			It("serialize concurrent writes", func() {
+				c := pubsub.NewConnection(server.URL())
+				defer c.Close()
+
+				c.AddTopic("video-playback-by-id.1")
+				Eventually(listened, 5*time.Second).Should(HaveKey("video-playback-by-id.1"))
+
+				var wg sync.WaitGroup
+				for w := 0; w < 10; w++ {
+					wg.Add(1)
+					go func(w int) {
+						defer wg.Done()
+						for i := 0; i < 5; i++ {
+							c.AddTopic(fmt.Sprintf("video-playback-by-id.%d", 100+w*5+i))
+						}
+					}(w)
+				}
+				wg.Wait()
+
+				Eventually(func() int {
+					return len(listened())
+				}, 5*time.Second).Should(Equal(50))
+			})
+		})
+	})
 })
 
 func TestSuite(t *testing.T) {
@@ -0,0 +1,85 @@
+package pubsub
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// DefaultReconnectPolicy is recommended by Twitch: start from 1 second and
+// double delay on every attempt up to 2 minutes, with random jitter.
+//
+// https://dev.twitch.tv/docs/pubsub/#connection-management
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialDelay: 1 * time.Second,
+	MaxDelay:     120 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+	MaxAttempts:  0,
+}
+
+// ErrReconnectAttempts is returned when all reconnect attempts failed.
+var ErrReconnectAttempts = errors.New("pubsub: reconnect attempts exceeded")
+
+// ReconnectPolicy is represent of delays between reconnect attempts.
+// First attempt is always immediate.
+type ReconnectPolicy struct {
+	// Delay before second attempt, zero means default delay
+	InitialDelay time.Duration
+
+	// Delay will not grow more than this value
+	MaxDelay time.Duration
+
+	// Delay will be multiplied by this value on every attempt
+	Multiplier float64
+
+	// Random part of delay, from 0 to 1, 0.2 means +/- 20%
+	Jitter float64
+
+	// Connection will give up after this count of failed attempts
+	// Zero means unlimited attempts
+	MaxAttempts int
+}
+
+// Delay returns delay after failed attempt, attempts starts from 1.
+func (p ReconnectPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	// Zero delay will hammer API with dials
+	initial := p.InitialDelay
+	if initial <= 0 {
+		initial = DefaultReconnectPolicy.InitialDelay
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return time.Duration(delay)
+}
+
+// Exceeded returns true if no more attempts allowed after failed attempt.
+func (p ReconnectPolicy) Exceeded(attempt int) bool {
+	return p.MaxAttempts > 0 && attempt >= p.MaxAttempts
+}
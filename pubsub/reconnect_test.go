@@ -0,0 +1,140 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReconnectPolicy", func() {
+	Context("Delay", func() {
+		It("grow delay exponentially up to max", func() {
+			p := pubsub.ReconnectPolicy{
+				InitialDelay: time.Second,
+				MaxDelay:     10 * time.Second,
+				Multiplier:   2,
+			}
+			Expect(p.Delay(1)).To(Equal(1 * time.Second))
+			Expect(p.Delay(2)).To(Equal(2 * time.Second))
+			Expect(p.Delay(3)).To(Equal(4 * time.Second))
+			Expect(p.Delay(4)).To(Equal(8 * time.Second))
+			Expect(p.Delay(5)).To(Equal(10 * time.Second))
+			Expect(p.Delay(100)).To(Equal(10 * time.Second))
+		})
+
+		It("add jitter", func() {
+			p := pubsub.ReconnectPolicy{
+				InitialDelay: 10 * time.Second,
+				MaxDelay:     time.Minute,
+				Multiplier:   2,
+				Jitter:       0.5,
+			}
+			for i := 0; i < 100; i++ {
+				Expect(p.Delay(1)).To(BeNumerically(">=", 5*time.Second))
+				Expect(p.Delay(1)).To(BeNumerically("<=", 15*time.Second))
+			}
+		})
+
+		It("use default initial delay if it's not set", func() {
+			p := pubsub.ReconnectPolicy{MaxAttempts: 5}
+			Expect(p.Delay(1)).To(Equal(pubsub.DefaultReconnectPolicy.InitialDelay))
+
+			p = pubsub.ReconnectPolicy{MaxDelay: time.Minute, Multiplier: 2}
+			Expect(p.Delay(2)).To(Equal(2 * pubsub.DefaultReconnectPolicy.InitialDelay))
+		})
+
+		It("follow Twitch recommendations by default", func() {
+			p := pubsub.DefaultReconnectPolicy
+			Expect(p.Delay(100)).To(BeNumerically("<=", 120*time.Second))
+			Expect(p.Exceeded(1000)).To(BeFalse())
+		})
+	})
+
+	Context("Connection", func() {
+		It("report attempts and give up", func() {
+			server := newFakeServer()
+			u := server.URL()
+			server.Close()
+
+			var mu sync.Mutex
+			attempts := []int{}
+			delays := []time.Duration{}
+			errs := []error{}
+
+			c := pubsub.NewConnection(u)
+			defer c.Close()
+
+			c.SetReconnectPolicy(pubsub.ReconnectPolicy{
+				InitialDelay: 10 * time.Millisecond,
+				MaxDelay:     15 * time.Millisecond,
+				Multiplier:   2,
+				MaxAttempts:  3,
+			})
+			c.OnReconnecting(func(c *pubsub.Connection, attempt int, delay time.Duration) {
+				mu.Lock()
+				defer mu.Unlock()
+				attempts = append(attempts, attempt)
+				delays = append(delays, delay)
+			})
+			c.OnError(func(c *pubsub.Connection, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+			})
+
+			result := c.AddTopic("video-playback-by-id.1")
+
+			Eventually(result.Done(), 5*time.Second).Should(BeClosed())
+			Expect(errors.Is(result.Err(), pubsub.ErrReconnectAttempts)).To(BeTrue())
+			Expect(errors.Is(c.AddTopic("video-playback-by-id.2").Err(), pubsub.ErrReconnectAttempts)).To(BeTrue())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(attempts).To(Equal([]int{2, 3}))
+			Expect(delays).To(Equal([]time.Duration{10 * time.Millisecond, 15 * time.Millisecond}))
+			Expect(errs).To(HaveLen(4))
+			Expect(errs[3]).To(MatchError(pubsub.ErrReconnectAttempts))
+		})
+	})
+
+	Context("PubSub", func() {
+		var ctx context.Context
+		var server *fakeServer
+		withFakeServer(&ctx, &server)
+
+		It("place topics again after connection gave up", func() {
+			server.SetRefuse(true)
+
+			ps := pubsub.NewWithURL(server.URL(), pubsub.WithReconnectPolicy(pubsub.ReconnectPolicy{
+				InitialDelay: 10 * time.Millisecond,
+				MaxDelay:     10 * time.Millisecond,
+				Multiplier:   1,
+				MaxAttempts:  2,
+			}))
+			defer ps.Close()
+
+			Expect(ps.Listen(ctx, pubsub.Polls("1")).Wait(ctx)).To(MatchError(pubsub.ErrReconnectAttempts))
+			Expect(ps.TopicStates()).To(Equal(map[string]pubsub.TopicState{"polls.1": pubsub.TopicFailed}))
+
+			server.SetRefuse(false)
+
+			// Connection which gave up is skipped
+			Expect(ps.Listen(ctx, pubsub.Polls("2")).Wait(ctx)).To(Succeed())
+			Expect(ps.Connections()).To(HaveLen(2))
+
+			// Topic is moved from connection which gave up, it's closed then
+			Expect(ps.Listen(ctx, pubsub.Polls("1")).Wait(ctx)).To(Succeed())
+			Expect(ps.Connections()).To(HaveLen(1))
+			Expect(ps.TopicStates()).To(Equal(map[string]pubsub.TopicState{
+				"polls.1": pubsub.TopicListening,
+				"polls.2": pubsub.TopicListening,
+			}))
+		})
+	})
+})
@@ -0,0 +1,188 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum time to wait RESPONSE for LISTEN or UNLISTEN request after it was
+// sent to API.
+const TwitchApiResponseTimeout = 10 * time.Second
+
+// Errors which can be returned by API in RESPONSE message.
+//
+// https://dev.twitch.tv/docs/pubsub/#topics
+var (
+	ErrBadMessage = errors.New("ERR_BADMESSAGE")
+	ErrBadAuth    = errors.New("ERR_BADAUTH")
+	ErrServer     = errors.New("ERR_SERVER")
+	ErrBadTopic   = errors.New("ERR_BADTOPIC")
+)
+
+// ErrTimeout is returned when API does not respond in time.
+var ErrTimeout = errors.New("pubsub: response timeout")
+
+// ErrClosed is returned when connection was closed before response.
+var ErrClosed = errors.New("pubsub: connection closed")
+
+// ErrWriteQueue is returned when write queue of connection is overflowed.
+var ErrWriteQueue = errors.New("pubsub: write queue is full")
+
+// ErrUnlistened is returned when topic was removed before response.
+var ErrUnlistened = errors.New("pubsub: topic unlistened")
+
+// ErrTopicLimit is returned when connection already has maximum topics.
+var ErrTopicLimit = errors.New("pubsub: topics limit reached")
+
+// ErrAlreadyListening is returned when topic is already listened
+// with the same auth token.
+var ErrAlreadyListening = errors.New("pubsub: topic already listening")
+
+// errNotConnected is returned by write when connection is not active,
+// requests will be sent after reconnect.
+var errNotConnected = errors.New("pubsub: not connected")
+
+// WriteError is represent of failed write of request to websocket.
+// It can be compared with ErrWriteQueue or websocket errors by errors.Is.
+type WriteError struct {
+	Type AnswerType
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("pubsub: write %s failed: %s", e.Type, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseError is represent of error returned by API for request.
+// It can be compared with ErrBadMessage, ErrBadAuth, ErrServer and
+// ErrBadTopic by errors.Is.
+type ResponseError struct {
+	Type   AnswerType
+	Nonce  string
+	Topics []string
+	Code   string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("pubsub: %s %s failed: %s", e.Type, strings.Join(e.Topics, ", "), e.Code)
+}
+
+func (e *ResponseError) Is(target error) bool {
+	return target != nil && target.Error() == e.Code
+}
+
+// -----------------------------------------------------------------------------
+
+// Result is represent of LISTEN or UNLISTEN request result.
+// It will be resolved when API respond with the same nonce or on timeout.
+type Result struct {
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+func newResult() *Result {
+	return &Result{done: make(chan struct{})}
+}
+
+func resolvedResult(err error) *Result {
+	r := newResult()
+	r.resolve(err)
+	return r
+}
+
+func (r *Result) resolve(err error) {
+	r.once.Do(func() {
+		r.err = err
+		close(r.done)
+	})
+}
+
+// Done returns channel which will be closed when result is known.
+func (r *Result) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns request error. It's nil if request succeeded or result is
+// not known yet.
+func (r *Result) Err() error {
+	select {
+	case <-r.done:
+		return r.err
+	default:
+		return nil
+	}
+}
+
+// succeeded returns true if result is known and request succeeded.
+func (r *Result) succeeded() bool {
+	select {
+	case <-r.done:
+		return r.err == nil
+	default:
+		return false
+	}
+}
+
+// Wait is waiting for result and returns request error.
+// Returns context error if context is done earlier.
+func (r *Result) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// request is represent of sent request which waiting for response.
+type request struct {
+	kind    AnswerType
+	topics  []string
+	results map[string][]*Result // topic -> results
+	timer   *time.Timer
+}
+
+func (r *request) resolve(err error) {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	for _, results := range r.results {
+		for _, result := range results {
+			result.resolve(err)
+		}
+	}
+}
+
+// forward is resolve result by another one.
+func forward(from, to *Result) {
+	select {
+	case <-from.Done():
+		to.resolve(from.Err())
+		return
+	default:
+	}
+
+	go func() {
+		<-from.Done()
+		to.resolve(from.Err())
+	}()
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
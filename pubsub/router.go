@@ -0,0 +1,83 @@
+package pubsub
+
+import (
+	"path"
+	"strings"
+)
+
+// MessageHandler is func which handle incoming message.
+type MessageHandler func(*Connection, *Answer)
+
+// Router is dispatch messages to handlers by topic. Pattern can be full
+// topic like whispers.123, only family like whispers or pattern
+// like channel-points-channel-v1.* in path.Match syntax.
+//
+// Zero value is ready to use, it can be bound to Connection.OnMessage
+// by Dispatch method.
+type Router struct {
+	routes   handlers[route]
+	fallback handlers[MessageHandler]
+}
+
+type route struct {
+	pattern string
+	fn      MessageHandler
+}
+
+// NewRouter create and returns new router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle is bind handler to topic pattern. All matched handlers will be
+// called in order of binding. Panics if pattern is malformed.
+// Returns func which unbind it.
+func (r *Router) Handle(pattern string, fn MessageHandler) func() {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic("pubsub: bad topic pattern " + pattern)
+	}
+	return r.routes.add(route{pattern: pattern, fn: fn})
+}
+
+// HandleDefault is bind handler for messages which not matched any pattern.
+// Returns func which unbind it.
+func (r *Router) HandleDefault(fn MessageHandler) func() {
+	return r.fallback.add(fn)
+}
+
+// Dispatch is pass message to matched handlers.
+func (r *Router) Dispatch(c *Connection, msg *Answer) {
+	for _, fn := range r.matched(msg.GetData().Topic) {
+		fn(c, msg)
+	}
+}
+
+// matched returns handlers matched by topic in order of binding,
+// or default handlers if there is no matched.
+func (r *Router) matched(topic string) []MessageHandler {
+	var result []MessageHandler
+	for _, item := range r.routes.all() {
+		if matchTopic(item.pattern, topic) {
+			result = append(result, item.fn)
+		}
+	}
+	if len(result) > 0 {
+		return result
+	}
+	return r.fallback.all()
+}
+
+// matchTopic returns true if topic is matched by pattern.
+func matchTopic(pattern, topic string) bool {
+	if pattern == topic {
+		return true
+	}
+
+	// Only family
+	if !strings.ContainsAny(pattern, ".*?[\\") {
+		return Topic(topic).Family() == pattern
+	}
+
+	ok, _ := path.Match(pattern, topic)
+	return ok
+}
@@ -0,0 +1,107 @@
+package pubsub_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Router", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	// message returns parsed message of topic
+	message := func(topic string) *pubsub.Answer {
+		msg := &pubsub.Answer{
+			Type: pubsub.Message,
+			Data: map[string]any{"topic": topic, "message": "{}"},
+		}
+		msg.Parse()
+		return msg
+	}
+
+	var r *pubsub.Router
+	var calls []string
+
+	bind := func(pattern string) func() {
+		return r.Handle(pattern, func(c *pubsub.Connection, msg *pubsub.Answer) {
+			calls = append(calls, pattern+" "+msg.GetData().Topic)
+		})
+	}
+
+	BeforeEach(func() {
+		r = pubsub.NewRouter()
+		calls = []string{}
+		r.HandleDefault(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			calls = append(calls, "default "+msg.GetData().Topic)
+		})
+	})
+
+	It("match topics by pattern", func() {
+		bind("whispers.123")
+		bind("channel-points-channel-v1.*")
+		bind("polls")
+
+		r.Dispatch(nil, message("whispers.123"))
+		r.Dispatch(nil, message("whispers.456"))
+		r.Dispatch(nil, message("channel-points-channel-v1.1"))
+		r.Dispatch(nil, message("polls.1"))
+		r.Dispatch(nil, message("polls-v2.1"))
+
+		Expect(calls).To(Equal([]string{
+			"whispers.123 whispers.123",
+			"default whispers.456",
+			"channel-points-channel-v1.* channel-points-channel-v1.1",
+			"polls polls.1",
+			"default polls-v2.1",
+		}))
+	})
+
+	It("call all matched handlers and unbind them", func() {
+		unbind := bind("whispers.*")
+		bind("whispers")
+
+		r.Dispatch(nil, message("whispers.1"))
+		unbind()
+		r.Dispatch(nil, message("whispers.2"))
+
+		Expect(calls).To(Equal([]string{
+			"whispers.* whispers.1",
+			"whispers whispers.1",
+			"whispers whispers.2",
+		}))
+	})
+
+	It("panic on bad pattern", func() {
+		Expect(func() { bind("whispers.[") }).To(Panic())
+	})
+
+	It("route messages of PubSub", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		matched := make(chan string, 2)
+		unmatched := make(chan string, 2)
+		ps.Handle("video-playback-by-id.1", func(c *pubsub.Connection, msg *pubsub.Answer) {
+			matched <- msg.GetData().Topic
+		})
+		ps.HandleDefault(func(c *pubsub.Connection, msg *pubsub.Answer) {
+			unmatched <- msg.GetData().Topic
+		})
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("2")).Wait(ctx)).To(Succeed())
+
+		server.Publish("video-playback-by-id.1", `{"type":"viewcount","viewers":1}`)
+		server.Publish("video-playback-by-id.2", `{"type":"viewcount","viewers":1}`)
+
+		Eventually(matched, 5*time.Second).Should(Receive(Equal("video-playback-by-id.1")))
+		Eventually(unmatched, 5*time.Second).Should(Receive(Equal("video-playback-by-id.2")))
+		Consistently(matched, 100*time.Millisecond).ShouldNot(Receive())
+	})
+})
@@ -0,0 +1,275 @@
+package pubsub_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// frame is decoded client request received by fake server.
+type frame struct {
+	Type  pubsub.AnswerType `json:"type"`
+	Nonce string            `json:"nonce"`
+	Data  struct {
+		Topics    []string `json:"topics"`
+		AuthToken string   `json:"auth_token"`
+	} `json:"data"`
+}
+
+// fakeServer is minimal Twitch PubSub server for tests.
+type fakeServer struct {
+	sync.Mutex
+
+	server  *httptest.Server
+	frames  []frame
+	conns   []*fakeConn
+	headers []http.Header
+
+	// reject returns error for LISTEN request or empty string
+	reject func(f frame) string
+
+	// mute disables PONG responses
+	mute bool
+
+	// delay of LISTEN responses
+	delay time.Duration
+
+	// refuse rejects websocket handshakes
+	refuse bool
+}
+
+type fakeConn struct {
+	sync.Mutex
+	ws *websocket.Conn
+}
+
+func (c *fakeConn) send(a pubsub.Answer) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, a.JSON())
+}
+
+func newFakeServer() *fakeServer {
+	s := &fakeServer{}
+	upgrader := websocket.Upgrader{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		refuse := s.refuse
+		s.Unlock()
+		if refuse {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		fc := &fakeConn{ws: ws}
+
+		s.Lock()
+		s.conns = append(s.conns, fc)
+		s.headers = append(s.headers, r.Header.Clone())
+		s.Unlock()
+
+		defer ws.Close()
+		for {
+			_, msg, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var f frame
+			if err := json.Unmarshal(msg, &f); err != nil {
+				continue
+			}
+
+			s.Lock()
+			s.frames = append(s.frames, f)
+			reject := s.reject
+			mute := s.mute
+			delay := s.delay
+			s.Unlock()
+
+			switch f.Type {
+			case pubsub.Ping:
+				if !mute {
+					_ = fc.send(pubsub.Answer{Type: pubsub.Pong})
+				}
+			case pubsub.Listen, pubsub.Unlisten:
+				answer := pubsub.Answer{Type: pubsub.Response, Nonce: f.Nonce}
+				if f.Type == pubsub.Listen && reject != nil {
+					answer.Error = reject(f)
+				}
+				if f.Type == pubsub.Listen && delay > 0 {
+					time.AfterFunc(delay, func() {
+						_ = fc.send(answer)
+					})
+					continue
+				}
+				_ = fc.send(answer)
+			}
+		}
+	}))
+	return s
+}
+
+// SetReject sets func which returns error for LISTEN request.
+func (s *fakeServer) SetReject(fn func(f frame) string) {
+	s.Lock()
+	defer s.Unlock()
+	s.reject = fn
+}
+
+// SetDelay sets delay of LISTEN responses.
+func (s *fakeServer) SetDelay(delay time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.delay = delay
+}
+
+// SetRefuse enables or disables rejecting of websocket handshakes.
+func (s *fakeServer) SetRefuse(refuse bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.refuse = refuse
+}
+
+// SetMute disables or enables PONG responses.
+func (s *fakeServer) SetMute(mute bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.mute = mute
+}
+
+func (s *fakeServer) URL() url.URL {
+	return url.URL{Scheme: "ws", Host: strings.TrimPrefix(s.server.URL, "http://"), Path: ""}
+}
+
+// Frames returns all received requests of type.
+func (s *fakeServer) Frames(t pubsub.AnswerType) []frame {
+	s.Lock()
+	defer s.Unlock()
+
+	frames := []frame{}
+	for _, f := range s.frames {
+		if f.Type == t {
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+// Headers returns handshake headers of all connections.
+func (s *fakeServer) Headers() []http.Header {
+	s.Lock()
+	defer s.Unlock()
+
+	return append([]http.Header{}, s.headers...)
+}
+
+// Publish sends message to all connected clients.
+func (s *fakeServer) Publish(topic, message string) {
+	s.Lock()
+	conns := append([]*fakeConn{}, s.conns...)
+	s.Unlock()
+
+	for _, c := range conns {
+		_ = c.send(pubsub.Answer{
+			Type: pubsub.Message,
+			Data: pubsub.AnswerDataMessage{Topic: topic, Message: message},
+		})
+	}
+}
+
+// DropConnections closes all connections from server side.
+func (s *fakeServer) DropConnections() {
+	s.Lock()
+	conns := s.conns
+	s.conns = nil
+	s.Unlock()
+
+	for _, c := range conns {
+		_ = c.ws.Close()
+	}
+}
+
+func (s *fakeServer) Close() {
+	s.DropConnections()
+	s.server.Close()
+}
+
+// withFakeServer registers creation of context with timeout and fake server
+// before every spec of container and their cleanup after it.
+func withFakeServer(ctx *context.Context, server **fakeServer) {
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		*ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		*server = newFakeServer()
+	})
+
+	AfterEach(func() {
+		cancel()
+		(*server).Close()
+	})
+}
+
+// videoTopics returns count of video playback topics starting from id.
+func videoTopics(from, count int) []pubsub.Topic {
+	topics := []pubsub.Topic{}
+	for i := from; i < from+count; i++ {
+		topics = append(topics, pubsub.VideoPlayback(fmt.Sprint(i)))
+	}
+	return topics
+}
+
+// stuckConn is client side connection which writes can be blocked
+// until connection is closed, it's used to fill write queue.
+type stuckConn struct {
+	net.Conn
+
+	stuck  *atomic.Bool
+	once   sync.Once
+	closed chan struct{}
+}
+
+// stuckDialer returns dialer which writes will be blocked when stuck is set.
+func stuckDialer(stuck *atomic.Bool) *websocket.Dialer {
+	return &websocket.Dialer{NetDial: func(network, addr string) (net.Conn, error) {
+		conn, err := net.Dial(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &stuckConn{Conn: conn, stuck: stuck, closed: make(chan struct{})}, nil
+	}}
+}
+
+func (c *stuckConn) Write(b []byte) (int, error) {
+	if c.stuck.Load() {
+		<-c.closed
+		return 0, errors.New("stuck connection closed")
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *stuckConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return c.Conn.Close()
+}
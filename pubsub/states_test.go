@@ -0,0 +1,72 @@
+package pubsub_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TopicStates", func() {
+	var ctx context.Context
+	var server *fakeServer
+	var ps *pubsub.PubSub
+	withFakeServer(&ctx, &server)
+
+	BeforeEach(func() {
+		ps = pubsub.NewWithURL(server.URL())
+	})
+
+	AfterEach(func() {
+		ps.Close()
+	})
+
+	It("send only new topic to live connection", func() {
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("2")).Wait(ctx)).To(Succeed())
+
+		frames := server.Frames(pubsub.Listen)
+		Expect(frames).To(HaveLen(2))
+		Expect(frames[0].Data.Topics).To(Equal([]string{"video-playback-by-id.1"}))
+		Expect(frames[1].Data.Topics).To(Equal([]string{"video-playback-by-id.2"}))
+
+		Expect(ps.TopicStates()).To(Equal(map[string]pubsub.TopicState{
+			"video-playback-by-id.1": pubsub.TopicListening,
+			"video-playback-by-id.2": pubsub.TopicListening,
+		}))
+	})
+
+	It("send all topics after reconnect", func() {
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(Succeed())
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("2")).Wait(ctx)).To(Succeed())
+
+		server.DropConnections()
+
+		Eventually(func() int {
+			return len(server.Frames(pubsub.Listen))
+		}, 5*time.Second).Should(Equal(3))
+		Expect(server.Frames(pubsub.Listen)[2].Data.Topics).To(Equal([]string{
+			"video-playback-by-id.1",
+			"video-playback-by-id.2",
+		}))
+
+		Eventually(ps.TopicStates, 5*time.Second).Should(Equal(map[string]pubsub.TopicState{
+			"video-playback-by-id.1": pubsub.TopicListening,
+			"video-playback-by-id.2": pubsub.TopicListening,
+		}))
+	})
+
+	It("mark failed topics", func() {
+		server.SetReject(func(f frame) string {
+			return "ERR_SERVER"
+		})
+
+		Expect(ps.Listen(ctx, pubsub.VideoPlayback("1")).Wait(ctx)).To(MatchError(pubsub.ErrServer))
+		Expect(ps.TopicStates()).To(Equal(map[string]pubsub.TopicState{
+			"video-playback-by-id.1": pubsub.TopicFailed,
+		}))
+	})
+})
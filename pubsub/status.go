@@ -0,0 +1,70 @@
+package pubsub
+
+import (
+	"time"
+)
+
+// ConnectionState is state of connection in status.
+type ConnectionState string
+
+const (
+	// ConnectionDialing is not connected yet and will dial
+	// as soon as it has topics
+	ConnectionDialing ConnectionState = "dialing"
+
+	// ConnectionConnected is connected to API
+	ConnectionConnected ConnectionState = "connected"
+
+	// ConnectionBackoff is waiting before next reconnect attempt
+	ConnectionBackoff ConnectionState = "backoff"
+
+	// ConnectionClosed is closed or gave up reconnecting
+	ConnectionClosed ConnectionState = "closed"
+)
+
+func (s ConnectionState) String() string {
+	return string(s)
+}
+
+// ConnectionStatus is snapshot of connection, it's not changed
+// by connection later.
+type ConnectionStatus struct {
+	ID    int64
+	Label string
+	URL   string
+	State ConnectionState
+
+	// Topics with their states
+	Topics map[string]TopicState
+
+	// Last ping round-trip time, zero if there was no PONG yet
+	LastRTT time.Duration
+
+	// Time of current connect, zero if not connected
+	ConnectedSince time.Time
+
+	// Count of successful reconnects after first connect
+	Reconnects int
+
+	// Count of received messages and dropped by dispatch overflow policy
+	Received uint64
+	Dropped  uint64
+}
+
+// Status is snapshot of API client, it's not changed by client later.
+type Status struct {
+	// Connections in order of creation
+	Connections []ConnectionStatus
+
+	// Topics which are waiting for free connection because of limits
+	Queued []string
+}
+
+// TopicsCount returns count of topics in all connections and queue.
+func (s Status) TopicsCount() int {
+	count := len(s.Queued)
+	for _, c := range s.Connections {
+		count += len(c.Topics)
+	}
+	return count
+}
@@ -0,0 +1,105 @@
+package pubsub_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Status", func() {
+	var ctx context.Context
+	var server *fakeServer
+	withFakeServer(&ctx, &server)
+
+	It("returns snapshot of connections", func() {
+		ps := pubsub.NewWithURL(server.URL(), pubsub.WithLabel("test"), pubsub.WithPingPolicy(pubsub.PingPolicy{
+			Interval: 20 * time.Millisecond,
+			Timeout:  time.Second,
+			Poll:     5 * time.Millisecond,
+		}))
+		defer ps.Close()
+
+		Expect(ps.Status().Connections).To(BeEmpty())
+
+		start := time.Now()
+		Expect(ps.Listen(ctx, pubsub.Polls("1")).Wait(ctx)).To(Succeed())
+
+		server.Publish("polls.1", "{}")
+		server.Publish("polls.1", "{}")
+
+		Eventually(func() uint64 {
+			return ps.Status().Connections[0].Received
+		}, 5*time.Second).Should(Equal(uint64(2)))
+		Eventually(func() time.Duration {
+			return ps.Status().Connections[0].LastRTT
+		}, 5*time.Second).Should(BeNumerically(">", 0))
+
+		status := ps.Status()
+		Expect(status.Connections).To(HaveLen(1))
+		Expect(status.Queued).To(BeEmpty())
+		Expect(status.TopicsCount()).To(Equal(1))
+
+		c := status.Connections[0]
+		Expect(c.ID).To(Equal(int64(0)))
+		Expect(c.Label).To(Equal("test#0"))
+		u := server.URL()
+		Expect(c.URL).To(Equal(u.String()))
+		Expect(c.State).To(Equal(pubsub.ConnectionConnected))
+		Expect(c.Topics).To(Equal(map[string]pubsub.TopicState{"polls.1": pubsub.TopicListening}))
+		Expect(c.ConnectedSince).To(BeTemporally(">=", start))
+		Expect(c.Reconnects).To(Equal(0))
+
+		// Snapshot is not changed by client
+		c.Topics["polls.2"] = pubsub.TopicPending
+		Expect(ps.Status().Connections[0].Topics).To(HaveLen(1))
+	})
+
+	It("count reconnects", func() {
+		ps := pubsub.NewWithURL(server.URL())
+		defer ps.Close()
+
+		Expect(ps.Listen(ctx, pubsub.Polls("1")).Wait(ctx)).To(Succeed())
+		since := ps.Status().Connections[0].ConnectedSince
+
+		server.DropConnections()
+
+		Eventually(func() int {
+			return ps.Status().Connections[0].Reconnects
+		}, 5*time.Second).Should(Equal(1))
+		Eventually(func() pubsub.TopicState {
+			return ps.Status().Connections[0].Topics["polls.1"]
+		}, 5*time.Second).Should(Equal(pubsub.TopicListening))
+
+		c := ps.Status().Connections[0]
+		Expect(c.State).To(Equal(pubsub.ConnectionConnected))
+		Expect(c.ConnectedSince).To(BeTemporally(">", since))
+	})
+
+	It("report backoff and closed states", func() {
+		u := server.URL()
+		server.Close()
+
+		c := pubsub.NewConnection(u, pubsub.WithReconnectPolicy(pubsub.ReconnectPolicy{
+			InitialDelay: time.Minute,
+			MaxDelay:     time.Minute,
+			Multiplier:   1,
+		}))
+
+		Expect(c.Status().State).To(Equal(pubsub.ConnectionDialing))
+
+		c.AddTopic("polls.1")
+
+		Eventually(func() pubsub.ConnectionState {
+			return c.Status().State
+		}, 5*time.Second).Should(Equal(pubsub.ConnectionBackoff))
+		Expect(c.Status().ConnectedSince.IsZero()).To(BeTrue())
+		Expect(c.Status().Topics).To(Equal(map[string]pubsub.TopicState{"polls.1": pubsub.TopicPending}))
+
+		Expect(c.Close()).To(Succeed())
+		Expect(c.Status().State).To(Equal(pubsub.ConnectionClosed))
+	})
+})
@@ -41,6 +41,8 @@ func (a *Answer) Parse() {
 	data := AnswerDataMessage{}
 
 	switch v := a.Data.(type) {
+	case AnswerDataMessage:
+		data = v
 	case map[string]any:
 		for fn, fv := range v {
 			if fn == "message" {
@@ -88,7 +90,8 @@ func (a AnswerDataMessage) JSON() []byte {
 // -----------------------------------------------------------------------------
 
 type AnswerDataTopics struct {
-	Topics []string `json:"topics"`
+	Topics    []string `json:"topics"`
+	AuthToken string   `json:"auth_token,omitempty"`
 }
 
 func (a AnswerDataTopics) JSON() []byte {
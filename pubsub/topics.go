@@ -0,0 +1,206 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Topic families documented by Twitch.
+//
+// https://dev.twitch.tv/docs/pubsub/#topics
+const (
+	FamilyBitsEvents           = "channel-bits-events-v2"
+	FamilyBitsBadgeUnlocks     = "channel-bits-badge-unlocks"
+	FamilyChannelPoints        = "channel-points-channel-v1"
+	FamilyChannelSubscriptions = "channel-subscribe-events-v1"
+	FamilyAutoModQueue         = "automod-queue"
+	FamilyChatModeratorActions = "chat_moderator_actions"
+	FamilyWhispers             = "whispers"
+	FamilyVideoPlayback        = "video-playback-by-id"
+	FamilyPredictions          = "predictions-channel-v1"
+	FamilyPolls                = "polls"
+	FamilyHypeTrain            = "hype-train-events-v1"
+)
+
+// topicFamilies is count of IDs required by known topic families.
+var topicFamilies = map[string]int{
+	FamilyBitsEvents:           1,
+	FamilyBitsBadgeUnlocks:     1,
+	FamilyChannelPoints:        1,
+	FamilyChannelSubscriptions: 1,
+	FamilyAutoModQueue:         2,
+	FamilyChatModeratorActions: 2,
+	FamilyWhispers:             1,
+	FamilyVideoPlayback:        1,
+	FamilyPredictions:          1,
+	FamilyPolls:                1,
+	FamilyHypeTrain:            1,
+}
+
+// ErrInvalidTopic is returned for malformed topics.
+var ErrInvalidTopic = errors.New("pubsub: invalid topic")
+
+// Topic is represent of API topic, family and IDs separated by dot.
+// Better to create it by constructors like ChannelPoints or Whispers.
+//
+// https://dev.twitch.tv/docs/pubsub/#topics
+type Topic string
+
+// NewTopic create topic from family and IDs.
+func NewTopic(family string, ids ...string) Topic {
+	return Topic(strings.Join(append([]string{family}, ids...), "."))
+}
+
+// ParseTopic parse and validate topic string.
+func ParseTopic(s string) (Topic, error) {
+	t := Topic(s)
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+	return t, nil
+}
+
+// BitsEvents returns channel-bits-events-v2 topic.
+func BitsEvents(channelID string) Topic {
+	return NewTopic(FamilyBitsEvents, channelID)
+}
+
+// BitsBadgeUnlocks returns channel-bits-badge-unlocks topic.
+func BitsBadgeUnlocks(channelID string) Topic {
+	return NewTopic(FamilyBitsBadgeUnlocks, channelID)
+}
+
+// ChannelPoints returns channel-points-channel-v1 topic.
+func ChannelPoints(channelID string) Topic {
+	return NewTopic(FamilyChannelPoints, channelID)
+}
+
+// ChannelSubscriptions returns channel-subscribe-events-v1 topic.
+func ChannelSubscriptions(channelID string) Topic {
+	return NewTopic(FamilyChannelSubscriptions, channelID)
+}
+
+// AutoModQueue returns automod-queue topic.
+func AutoModQueue(moderatorID, channelID string) Topic {
+	return NewTopic(FamilyAutoModQueue, moderatorID, channelID)
+}
+
+// ChatModeratorActions returns chat_moderator_actions topic.
+func ChatModeratorActions(userID, channelID string) Topic {
+	return NewTopic(FamilyChatModeratorActions, userID, channelID)
+}
+
+// Whispers returns whispers topic.
+func Whispers(userID string) Topic {
+	return NewTopic(FamilyWhispers, userID)
+}
+
+// VideoPlayback returns video-playback-by-id topic.
+func VideoPlayback(channelID string) Topic {
+	return NewTopic(FamilyVideoPlayback, channelID)
+}
+
+// Predictions returns predictions-channel-v1 topic.
+func Predictions(channelID string) Topic {
+	return NewTopic(FamilyPredictions, channelID)
+}
+
+// Polls returns polls topic.
+func Polls(channelID string) Topic {
+	return NewTopic(FamilyPolls, channelID)
+}
+
+// HypeTrain returns hype-train-events-v1 topic.
+func HypeTrain(channelID string) Topic {
+	return NewTopic(FamilyHypeTrain, channelID)
+}
+
+// -----------------------------------------------------------------------------
+
+// Family returns topic family, for example whispers.
+func (t Topic) Family() string {
+	family, _, _ := strings.Cut(string(t), ".")
+	return family
+}
+
+// IDs returns topic IDs, for example user ID and channel ID.
+func (t Topic) IDs() []string {
+	_, ids, ok := strings.Cut(string(t), ".")
+	if !ok {
+		return []string{}
+	}
+	return strings.Split(ids, ".")
+}
+
+// Validate returns error if topic is malformed. Known families must have
+// correct count of numeric IDs, unknown families at least one ID.
+func (t Topic) Validate() error {
+	if t == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidTopic)
+	}
+
+	if strings.ContainsAny(string(t), " \t\r\n") {
+		return fmt.Errorf("%w: %q contains spaces", ErrInvalidTopic, t)
+	}
+
+	if t.Family() == "" {
+		return fmt.Errorf("%w: %q has empty family", ErrInvalidTopic, t)
+	}
+
+	ids := t.IDs()
+	if len(ids) <= 0 {
+		return fmt.Errorf("%w: %q has no IDs", ErrInvalidTopic, t)
+	}
+
+	for _, id := range ids {
+		if id == "" {
+			return fmt.Errorf("%w: %q has empty ID", ErrInvalidTopic, t)
+		}
+	}
+
+	count, ok := topicFamilies[t.Family()]
+	if !ok {
+		return nil
+	}
+
+	if len(ids) != count {
+		return fmt.Errorf("%w: %q must have %d IDs", ErrInvalidTopic, t, count)
+	}
+
+	for _, id := range ids {
+		if strings.Trim(id, "0123456789") != "" {
+			return fmt.Errorf("%w: %q has not numeric ID", ErrInvalidTopic, t)
+		}
+	}
+
+	return nil
+}
+
+func (t Topic) String() string {
+	return string(t)
+}
+
+// -----------------------------------------------------------------------------
+
+// TopicState is state of topic in connection.
+type TopicState string
+
+const (
+	// TopicPending is not sent or not answered by API yet
+	TopicPending TopicState = "pending"
+
+	// TopicListening is acknowledged by API
+	TopicListening TopicState = "listening"
+
+	// TopicFailed is answered with error or not answered in time,
+	// it will be sent again after reconnect
+	TopicFailed TopicState = "failed"
+
+	// TopicQueued is waiting for free connection because of limits
+	TopicQueued TopicState = "queued"
+)
+
+func (s TopicState) String() string {
+	return string(s)
+}
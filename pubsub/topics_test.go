@@ -0,0 +1,92 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/vladimirok5959/golang-twitch/pubsub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Topics", func() {
+	Context("Constructors", func() {
+		It("generate correct topics", func() {
+			Expect(pubsub.BitsEvents("1")).To(Equal(pubsub.Topic("channel-bits-events-v2.1")))
+			Expect(pubsub.BitsBadgeUnlocks("1")).To(Equal(pubsub.Topic("channel-bits-badge-unlocks.1")))
+			Expect(pubsub.ChannelPoints("1")).To(Equal(pubsub.Topic("channel-points-channel-v1.1")))
+			Expect(pubsub.ChannelSubscriptions("1")).To(Equal(pubsub.Topic("channel-subscribe-events-v1.1")))
+			Expect(pubsub.AutoModQueue("1", "2")).To(Equal(pubsub.Topic("automod-queue.1.2")))
+			Expect(pubsub.ChatModeratorActions("1", "2")).To(Equal(pubsub.Topic("chat_moderator_actions.1.2")))
+			Expect(pubsub.Whispers("1")).To(Equal(pubsub.Topic("whispers.1")))
+			Expect(pubsub.VideoPlayback("1")).To(Equal(pubsub.Topic("video-playback-by-id.1")))
+			Expect(pubsub.Predictions("1")).To(Equal(pubsub.Topic("predictions-channel-v1.1")))
+			Expect(pubsub.Polls("1")).To(Equal(pubsub.Topic("polls.1")))
+			Expect(pubsub.HypeTrain("1")).To(Equal(pubsub.Topic("hype-train-events-v1.1")))
+		})
+	})
+
+	Context("ParseTopic", func() {
+		It("parse family and IDs", func() {
+			t, err := pubsub.ParseTopic("chat_moderator_actions.123.456")
+			Expect(err).To(Succeed())
+			Expect(t.Family()).To(Equal(pubsub.FamilyChatModeratorActions))
+			Expect(t.IDs()).To(Equal([]string{"123", "456"}))
+		})
+
+		It("accept unknown families", func() {
+			t, err := pubsub.ParseTopic("community-points-channel-v1.1.2")
+			Expect(err).To(Succeed())
+			Expect(t.Family()).To(Equal("community-points-channel-v1"))
+			Expect(t.IDs()).To(Equal([]string{"1", "2"}))
+		})
+
+		It("reject malformed topics", func() {
+			for _, s := range []string{
+				"",
+				"whispers",
+				"whispers.",
+				".123",
+				"whispers.1 2",
+				"whispers.abc",
+				"whispers.1.2",
+				"chat_moderator_actions.123",
+				"unknown..1",
+			} {
+				_, err := pubsub.ParseTopic(s)
+				Expect(errors.Is(err, pubsub.ErrInvalidTopic)).To(BeTrue(), s)
+			}
+		})
+	})
+
+	Context("PubSub", func() {
+		var ctx = context.Background()
+		var ps *pubsub.PubSub
+
+		BeforeEach(func() {
+			ps = pubsub.NewWithURL(url.URL{Scheme: "ws", Host: "example.com", Path: ""})
+		})
+
+		AfterEach(func() {
+			ps.Close()
+		})
+
+		It("accept typed topics", func() {
+			ps.Listen(ctx, pubsub.ChannelPoints("123"))
+			Expect(ps.HasTopic(pubsub.ChannelPoints("123"))).To(BeTrue())
+			Expect(ps.HasTopic("channel-points-channel-v1", 123)).To(BeTrue())
+
+			ps.Unlisten(ctx, pubsub.ChannelPoints("123"))
+			Expect(ps.HasTopic(pubsub.ChannelPoints("123"))).To(BeFalse())
+		})
+
+		It("not listen malformed topics", func() {
+			err := ps.Listen(ctx, pubsub.Whispers("")).Err()
+			Expect(errors.Is(err, pubsub.ErrInvalidTopic)).To(BeTrue())
+			Expect(ps.TopicsCount()).To(Equal(0))
+			Expect(len(ps.Connections())).To(Equal(0))
+		})
+	})
+})